@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// providerMetadata is the subset of the OIDC discovery document
+// (/.well-known/openid-configuration) the authenticator relies on.
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oauth2Config mirrors the handful of fields the authenticator needs from
+// golang.org/x/oauth2's Config without taking the dependency.
+type oauth2Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	Endpoint     providerMetadata
+}
+
+// Authenticator implements an OIDC authorization-code login flow: it
+// discovers the provider, redirects to its consent screen, and on
+// callback exchanges the code, verifies the ID token, and maps claims
+// onto the existing User struct.
+type Authenticator struct {
+	cfg      AuthConfig
+	provider providerMetadata
+	oauth    oauth2Config
+	client   *http.Client
+	jwks     jwksDocument
+}
+
+// NewAuthenticator discovers cfg.OIDCIssuer's provider metadata, fetches
+// its JWKS, and returns an Authenticator ready to serve
+// LoginHandler/CallbackHandler.
+func NewAuthenticator(ctx context.Context, cfg AuthConfig) (*Authenticator, error) {
+	provider, err := discoverProvider(ctx, cfg.OIDCIssuer)
+	if err != nil {
+		return nil, fmt.Errorf("auth: discover provider: %w", err)
+	}
+
+	jwks, err := fetchJWKS(ctx, provider.JWKSURI)
+	if err != nil {
+		return nil, fmt.Errorf("auth: fetch JWKS: %w", err)
+	}
+
+	scopes := cfg.OIDCScopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+
+	return &Authenticator{
+		cfg:      cfg,
+		provider: provider,
+		oauth: oauth2Config{
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       scopes,
+			Endpoint:     provider,
+		},
+		client: http.DefaultClient,
+		jwks:   jwks,
+	}, nil
+}
+
+// jwksKey is one entry of a JWKS document, kept to the RSA fields this
+// authenticator supports (RS256).
+type jwksKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwksDocument is the provider's published key set, fetched once from
+// providerMetadata.JWKSURI and reused to verify every ID token.
+type jwksDocument struct {
+	Keys []jwksKey `json:"keys"`
+}
+
+// publicKey decodes k's RSA modulus/exponent into an *rsa.PublicKey.
+func (k jwksKey) publicKey() (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("decode modulus: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("decode exponent: %w", err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
+
+func fetchJWKS(ctx context.Context, uri string) (jwksDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return jwksDocument{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return jwksDocument{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return jwksDocument{}, fmt.Errorf("jwks request failed with status %d", resp.StatusCode)
+	}
+
+	var doc jwksDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return jwksDocument{}, err
+	}
+	return doc, nil
+}
+
+func discoverProvider(ctx context.Context, issuer string) (providerMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return providerMetadata{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return providerMetadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return providerMetadata{}, fmt.Errorf("discovery request failed with status %d", resp.StatusCode)
+	}
+
+	var meta providerMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return providerMetadata{}, err
+	}
+	return meta, nil
+}
+
+// stateCookieName is the signed cookie holding the pending OIDC state.
+const stateCookieName = "oidc_state"
+
+// LoginHandler redirects the user to the provider's authorization
+// endpoint, stashing a signed state value in a cookie for CallbackHandler
+// to verify.
+func (a *Authenticator) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	state, err := randomState()
+	if err != nil {
+		http.Error(w, "failed to start login", http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    a.signState(state),
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   int((10 * time.Minute).Seconds()),
+	})
+
+	values := url.Values{
+		"client_id":     {a.oauth.ClientID},
+		"redirect_uri":  {a.oauth.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {strings.Join(a.oauth.Scopes, " ")},
+		"state":         {state},
+	}
+	http.Redirect(w, r, a.provider.AuthorizationEndpoint+"?"+values.Encode(), http.StatusFound)
+}
+
+// CallbackHandler validates state, exchanges the authorization code, and
+// verifies the returned ID token before populating a User.
+func (a *Authenticator) CallbackHandler(onSuccess func(w http.ResponseWriter, r *http.Request, u *User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(stateCookieName)
+		if err != nil {
+			http.Error(w, "missing state cookie", http.StatusBadRequest)
+			return
+		}
+
+		state := r.URL.Query().Get("state")
+		if state == "" || a.signState(state) != cookie.Value {
+			http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+			return
+		}
+
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "missing authorization code", http.StatusBadRequest)
+			return
+		}
+
+		tokens, err := a.exchangeCode(r.Context(), code)
+		if err != nil {
+			http.Error(w, "token exchange failed: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := verifyIDToken(tokens.IDToken, a.jwks)
+		if err != nil {
+			http.Error(w, "invalid ID token: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		username := stringClaim(claims, "preferred_username")
+		if username == "" {
+			username = stringClaim(claims, "email")
+		}
+		user := &User{
+			Username: username,
+			Email:    stringClaim(claims, "email"),
+			Role:     stringClaim(claims, "role"),
+		}
+		onSuccess(w, r, user)
+	}
+}
+
+// LogoutHandler clears the oidc_state cookie LoginHandler set. It does
+// not call the provider's end-session endpoint, since not all providers
+// expose one.
+func (a *Authenticator) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   stateCookieName,
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (a *Authenticator) exchangeCode(ctx context.Context, code string) (*tokenResponse, error) {
+	values := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {a.oauth.RedirectURL},
+		"client_id":     {a.oauth.ClientID},
+		"client_secret": {a.oauth.ClientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.provider.TokenEndpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tok tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return nil, err
+	}
+	return &tok, nil
+}
+
+// verifyIDToken checks idToken's RS256 signature against jwks (matching
+// by the header's "kid") before decoding and returning its claims.
+func verifyIDToken(idToken string, jwks jwksDocument) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported ID token signing algorithm %q", header.Alg)
+	}
+
+	var key jwksKey
+	found := false
+	for _, k := range jwks.Keys {
+		if k.Kid == header.Kid {
+			key = k
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no JWKS key matches ID token kid %q", header.Kid)
+	}
+
+	pubKey, err := key.publicKey()
+	if err != nil {
+		return nil, fmt.Errorf("parse JWKS key: %w", err)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, hash[:], sig); err != nil {
+		return nil, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+	return claims, nil
+}
+
+func stringClaim(claims map[string]interface{}, key string) string {
+	if v, ok := claims[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func randomState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (a *Authenticator) signState(state string) string {
+	mac := hmac.New(sha256.New, []byte(a.cfg.SessionSecret))
+	mac.Write([]byte(state))
+	return state + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// contextKey namespaces values this package stores in request contexts.
+type contextKey string
+
+const userContextKey contextKey = "auth.user"
+const scopeContextKey contextKey = "auth.scope"
+
+// RequireAuth rejects requests that have no authenticated *User attached
+// to their context (see AuthMiddleware, which populates it).
+func RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(userContextKey).(*User); !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole rejects requests whose authenticated user's Role does not
+// match role.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(userContextKey).(*User)
+			if !ok || user.Role != role {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
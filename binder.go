@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// HTTPError is the stable JSON shape returned for binding/encoding
+// failures, so clients can rely on {code, message, details} regardless
+// of which decoder rejected the request.
+type HTTPError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+}
+
+func (e *HTTPError) Error() string { return e.Message }
+
+// Binder decodes an HTTP request body into v, choosing a format based on
+// Content-Type (or, for bodyless requests, the query string).
+type Binder interface {
+	Bind(r *http.Request, v interface{}) error
+}
+
+// DefaultBinder dispatches to JSON, XML, or form decoding based on
+// Content-Type, and reads from the query string for GET/DELETE.
+type DefaultBinder struct{}
+
+// requestBinder is the Binder every handler uses to decode request
+// bodies, letting clients POST JSON, XML, or form-encoded payloads.
+var requestBinder Binder = DefaultBinder{}
+
+// Bind implements Binder.
+func (DefaultBinder) Bind(r *http.Request, v interface{}) error {
+	if r.Method == http.MethodGet || r.Method == http.MethodDelete {
+		return bindQuery(r, v)
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		contentType = "application/json"
+	}
+
+	switch contentType {
+	case "application/xml", "text/xml":
+		return xml.NewDecoder(r.Body).Decode(v)
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		return bindForm(r, v)
+	default:
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+}
+
+// bindQuery populates v's exported fields from the request's query
+// parameters, matching each field's `json` tag.
+func bindQuery(r *http.Request, v interface{}) error {
+	values := map[string]interface{}{}
+	for key, vals := range r.URL.Query() {
+		if len(vals) == 1 {
+			values[key] = vals[0]
+		} else {
+			values[key] = vals
+		}
+	}
+	return remarshal(values, v)
+}
+
+// bindForm populates v from a urlencoded or multipart form body.
+func bindForm(r *http.Request, v interface{}) error {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return err
+		}
+	} else if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{}
+	for key, vals := range r.Form {
+		if len(vals) == 1 {
+			values[key] = vals[0]
+		} else {
+			values[key] = vals
+		}
+	}
+	return remarshal(values, v)
+}
+
+// remarshal is a small json-tag-respecting bridge from a
+// map[string]interface{} to an arbitrary struct pointer.
+func remarshal(values map[string]interface{}, v interface{}) error {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Render writes v to w in whichever format r's Accept header prefers,
+// defaulting to JSON.
+func Render(w http.ResponseWriter, r *http.Request, status int, v interface{}) error {
+	accept := r.Header.Get("Accept")
+
+	switch {
+	case strings.Contains(accept, "application/xml"), strings.Contains(accept, "text/xml"):
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(status)
+		return xml.NewEncoder(w).Encode(v)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		return json.NewEncoder(w).Encode(v)
+	}
+}
+
+// WriteHTTPError renders code, message, and details (joined with "; ")
+// as the stable HTTPError JSON shape.
+func WriteHTTPError(w http.ResponseWriter, status int, code, message string, details ...string) {
+	detail := ""
+	if len(details) > 0 {
+		detail = strings.Join(details, "; ")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&HTTPError{Code: code, Message: message, Details: detail})
+}
+
+// bindDecodeError wraps a lower-level decode failure as an HTTPError,
+// used by handlers migrating off ad-hoc json.NewDecoder calls.
+func bindDecodeError(err error) *HTTPError {
+	return &HTTPError{Code: "E_BAD_REQUEST", Message: "invalid request body", Details: err.Error()}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// CouponType selects how a coupon's discount is computed.
+type CouponType string
+
+const (
+	CouponPercentage   CouponType = "percentage"
+	CouponFixed        CouponType = "fixed"
+	CouponFreeShipping CouponType = "free_shipping"
+)
+
+// CouponModel represents a discount code and the rules that constrain
+// when it may be applied.
+type CouponModel struct {
+	BaseModel
+	Code               string     `json:"code" gorm:"uniqueIndex;not null"`
+	Type               CouponType `json:"type" gorm:"type:varchar(20);not null"`
+	Value              float64    `json:"value" gorm:"type:decimal(10,2);not null"`
+	MinSubtotal        float64    `json:"min_subtotal" gorm:"type:decimal(10,2);default:0"`
+	PerUserLimit       int        `json:"per_user_limit" gorm:"default:0"` // 0 = unlimited
+	GlobalLimit        int        `json:"global_limit" gorm:"default:0"`   // 0 = unlimited
+	AllowedProductIDs  []uint     `json:"allowed_product_ids" gorm:"-"`
+	DeniedProductIDs   []uint     `json:"denied_product_ids" gorm:"-"`
+	AllowedCategoryIDs []uint     `json:"allowed_category_ids" gorm:"-"`
+	DeniedCategoryIDs  []uint     `json:"denied_category_ids" gorm:"-"`
+	StartsAt           time.Time  `json:"starts_at"`
+	EndsAt             time.Time  `json:"ends_at"`
+	Stackable          bool       `json:"stackable" gorm:"default:false"`
+	ExclusiveGroup     string     `json:"exclusive_group"`
+	IsActive           bool       `json:"is_active" gorm:"default:true"`
+}
+
+// CouponUsageModel records one redemption of a coupon, so per-user and
+// global caps can be enforced and rolled back on cancellation/refund.
+type CouponUsageModel struct {
+	BaseModel
+	CouponID uint    `json:"coupon_id" gorm:"not null"`
+	UserID   uint    `json:"user_id" gorm:"not null"`
+	OrderID  uint    `json:"order_id" gorm:"not null"`
+	Amount   float64 `json:"amount" gorm:"type:decimal(10,2);not null"`
+	Rolled   bool    `json:"rolled_back" gorm:"default:false"`
+}
+
+// Breakdown reports the outcome of applying one or more coupons to a
+// cart: which ones were accepted and the total discount produced.
+type Breakdown struct {
+	Applied  []CouponModel
+	Discount float64
+}
+
+// CouponStore is the persistence surface the engine needs: look up
+// coupons by code and count prior usage against the caps.
+type CouponStore interface {
+	FindCoupon(code string) (CouponModel, bool, error)
+	UsageCount(couponID uint) (int, error)
+	UserUsageCount(couponID, userID uint) (int, error)
+}
+
+// CouponEngine evaluates coupon codes against a cart and picks the best
+// non-conflicting combination.
+type CouponEngine struct {
+	store CouponStore
+}
+
+// NewCouponEngine returns a CouponEngine backed by store.
+func NewCouponEngine(store CouponStore) *CouponEngine {
+	return &CouponEngine{store: store}
+}
+
+// isValidNow reports whether c is active and within its validity window.
+func (c CouponModel) isValidNow(now time.Time) bool {
+	if !c.IsActive {
+		return false
+	}
+	if !c.StartsAt.IsZero() && now.Before(c.StartsAt) {
+		return false
+	}
+	if !c.EndsAt.IsZero() && now.After(c.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// appliesToItem reports whether coupon c applies to an item for the
+// given product and category, honoring allow/deny lists. Empty allow
+// lists mean "applies to everything not denied".
+func (c CouponModel) appliesToItem(productID, categoryID uint) bool {
+	for _, id := range c.DeniedProductIDs {
+		if id == productID {
+			return false
+		}
+	}
+	for _, id := range c.DeniedCategoryIDs {
+		if id == categoryID {
+			return false
+		}
+	}
+	if len(c.AllowedProductIDs) == 0 && len(c.AllowedCategoryIDs) == 0 {
+		return true
+	}
+	for _, id := range c.AllowedProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	for _, id := range c.AllowedCategoryIDs {
+		if id == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// eligibleSubtotal sums the line totals of the cart items c.appliesToItem
+// allows, i.e. the portion of the cart c is actually allowed to discount.
+func (c CouponModel) eligibleSubtotal(cart *CartModel) float64 {
+	total := 0.0
+	for _, item := range cart.Items {
+		if !c.appliesToItem(item.ProductID, item.Product.CategoryID) {
+			continue
+		}
+		total += item.Product.Price * float64(item.Quantity)
+	}
+	return total
+}
+
+// discountFor computes the discount coupon c yields against
+// eligibleSubtotal, the portion of the cart it's allowed to discount.
+func (c CouponModel) discountFor(eligibleSubtotal, shippingAmount float64) float64 {
+	switch c.Type {
+	case CouponPercentage:
+		return eligibleSubtotal * c.Value / 100
+	case CouponFixed:
+		if c.Value > eligibleSubtotal {
+			return eligibleSubtotal
+		}
+		return c.Value
+	case CouponFreeShipping:
+		return shippingAmount
+	default:
+		return 0
+	}
+}
+
+// eligible checks usage caps and the min-subtotal threshold for coupon
+// against cart, without yet deciding whether it stacks with others.
+func (e *CouponEngine) eligible(coupon CouponModel, cart *CartModel, subtotal float64) error {
+	if !coupon.isValidNow(time.Now()) {
+		return fmt.Errorf("coupon: %q is not currently valid", coupon.Code)
+	}
+	if subtotal < coupon.MinSubtotal {
+		return fmt.Errorf("coupon: %q requires a minimum subtotal of %.2f", coupon.Code, coupon.MinSubtotal)
+	}
+	if coupon.GlobalLimit > 0 {
+		used, err := e.store.UsageCount(coupon.ID)
+		if err != nil {
+			return err
+		}
+		if used >= coupon.GlobalLimit {
+			return fmt.Errorf("coupon: %q has reached its usage limit", coupon.Code)
+		}
+	}
+	if coupon.PerUserLimit > 0 {
+		used, err := e.store.UserUsageCount(coupon.ID, cart.UserID)
+		if err != nil {
+			return err
+		}
+		if used >= coupon.PerUserLimit {
+			return fmt.Errorf("coupon: %q has already been used the maximum number of times by this user", coupon.Code)
+		}
+	}
+	return nil
+}
+
+// Evaluate resolves codes against cart and greedily picks the
+// best non-conflicting combination: coupons sharing an ExclusiveGroup
+// (or any non-stackable coupon after the first) are mutually exclusive.
+// Coupons are ranked by their individual discount and accepted in that
+// order in a single pass; combinations are not scored against each
+// other.
+func (e *CouponEngine) Evaluate(cart *CartModel, codes []string) (applied []CouponModel, discount float64, err error) {
+	subtotal := 0.0
+	for _, item := range cart.Items {
+		subtotal += item.Product.Price * float64(item.Quantity)
+	}
+
+	var candidates []CouponModel
+	for _, code := range codes {
+		coupon, ok, lookupErr := e.store.FindCoupon(code)
+		if lookupErr != nil {
+			return nil, 0, lookupErr
+		}
+		if !ok {
+			return nil, 0, fmt.Errorf("coupon: %q not found", code)
+		}
+		if err := e.eligible(coupon, cart, subtotal); err != nil {
+			return nil, 0, err
+		}
+		candidates = append(candidates, coupon)
+	}
+
+	// Greedily accept the highest-value coupon first, then add any
+	// remaining stackable coupon that doesn't share an ExclusiveGroup
+	// with something already accepted. Each coupon is scored against
+	// its own eligible subtotal (only the items its allow/deny lists
+	// cover), not the whole cart.
+	sortByDiscountDesc(candidates, cart)
+
+	usedGroups := map[string]bool{}
+	for _, c := range candidates {
+		if c.ExclusiveGroup != "" && usedGroups[c.ExclusiveGroup] {
+			continue
+		}
+		if len(applied) > 0 && !c.Stackable {
+			continue
+		}
+		applied = append(applied, c)
+		discount += c.discountFor(c.eligibleSubtotal(cart), 0)
+		if c.ExclusiveGroup != "" {
+			usedGroups[c.ExclusiveGroup] = true
+		}
+	}
+	if discount > subtotal {
+		discount = subtotal
+	}
+	return applied, discount, nil
+}
+
+func sortByDiscountDesc(coupons []CouponModel, cart *CartModel) {
+	for i := 1; i < len(coupons); i++ {
+		for j := i; j > 0; j-- {
+			if coupons[j].discountFor(coupons[j].eligibleSubtotal(cart), 0) > coupons[j-1].discountFor(coupons[j-1].eligibleSubtotal(cart), 0) {
+				coupons[j], coupons[j-1] = coupons[j-1], coupons[j]
+			} else {
+				break
+			}
+		}
+	}
+}
+
+// ApplyCoupon evaluates code against cart via engine and, on success,
+// records the winning combination as the cart's applied coupon.
+func (cart *CartModel) ApplyCoupon(engine *CouponEngine, code string) (Breakdown, error) {
+	applied, discount, err := engine.Evaluate(cart, []string{code})
+	if err != nil {
+		return Breakdown{}, err
+	}
+	if len(applied) > 0 {
+		cart.AppliedCouponID = &applied[0].ID
+	}
+	return Breakdown{Applied: applied, Discount: discount}, nil
+}
+
+// RecordUsage persists usage rows for applied coupons against order and
+// sets order.DiscountAmount/AppliedCouponID. Call this when an order is
+// created from a cart carrying applied coupons.
+func RecordUsage(store interface {
+	SaveUsage(CouponUsageModel) error
+}, order *OrderModel, applied []CouponModel, discount float64) error {
+	order.DiscountAmount = discount
+	if len(applied) > 0 {
+		order.AppliedCouponID = &applied[0].ID
+	}
+	perCoupon := discount
+	if len(applied) > 1 {
+		perCoupon = discount / float64(len(applied))
+	}
+	for _, c := range applied {
+		usage := CouponUsageModel{CouponID: c.ID, UserID: order.UserID, OrderID: order.ID, Amount: perCoupon}
+		if err := store.SaveUsage(usage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RollbackUsage marks order's coupon usage rows as rolled back, invoked
+// when an order is cancelled or refunded so the coupon's usage caps
+// free back up.
+func RollbackUsage(store interface {
+	RollbackOrderUsage(orderID uint) error
+}, order *OrderModel) error {
+	return store.RollbackOrderUsage(order.ID)
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// EventPublisher publishes domain events and supports the request/reply
+// pattern so other services can query read models (e.g. "order.get_by_id")
+// without touching the database directly.
+type EventPublisher interface {
+	Publish(subject string, payload interface{}) error
+	Request(subject string, payload interface{}, out interface{}) error
+}
+
+// Publishable is implemented by models that want their lifecycle hooks
+// to emit domain events. ToEvent returns the event subject (without the
+// publisher's prefix) and a JSON-safe payload struct for eventType, or
+// ok=false if the model has nothing to say about that event.
+type Publishable interface {
+	ToEvent(eventType string) (subject string, payload interface{}, ok bool)
+}
+
+// Domain event payloads. These are intentionally separate from the GORM
+// models so internal columns (Password, raw foreign keys, etc.) never
+// leak onto the wire.
+
+// OrderCreatedEvent is published on "order.created".
+type OrderCreatedEvent struct {
+	OrderID     uint    `json:"order_id"`
+	OrderNumber string  `json:"order_number"`
+	UserID      uint    `json:"user_id"`
+	TotalAmount float64 `json:"total_amount"`
+	Currency    string  `json:"currency"`
+}
+
+// OrderStatusChangedEvent is published on "order.status_changed".
+type OrderStatusChangedEvent struct {
+	OrderID uint        `json:"order_id"`
+	From    OrderStatus `json:"from"`
+	To      OrderStatus `json:"to"`
+	ActorID uint        `json:"actor_id"`
+}
+
+// CartItemAddedEvent is published on "cart.item_added".
+type CartItemAddedEvent struct {
+	CartID    uint `json:"cart_id"`
+	ProductID uint `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+// ProductStockLowEvent is published on "product.stock_low" when a
+// product's aggregate stock drops at or below its low-stock threshold.
+type ProductStockLowEvent struct {
+	ProductID     uint `json:"product_id"`
+	StockQuantity int  `json:"stock_quantity"`
+	Threshold     int  `json:"threshold"`
+}
+
+// ToEvent implements Publishable for CartItemModel.
+func (c *CartItemModel) ToEvent(eventType string) (string, interface{}, bool) {
+	switch eventType {
+	case "added":
+		return "cart.item_added", CartItemAddedEvent{CartID: c.CartID, ProductID: c.ProductID, Quantity: c.Quantity}, true
+	default:
+		return "", nil, false
+	}
+}
+
+// AfterCreate publishes "cart.item_added" through the default event bus.
+func (c *CartItemModel) AfterCreate() error {
+	if err := c.BaseModel.AfterCreate(); err != nil {
+		return err
+	}
+	return emitLifecycleEvent(defaultEventPublisher, "added", c)
+}
+
+// ToEvent implements Publishable for OrderModel.
+func (o *OrderModel) ToEvent(eventType string) (string, interface{}, bool) {
+	switch eventType {
+	case "created":
+		return "order.created", OrderCreatedEvent{
+			OrderID:     o.ID,
+			OrderNumber: o.OrderNumber,
+			UserID:      o.UserID,
+			TotalAmount: o.TotalAmount,
+			Currency:    o.Currency,
+		}, true
+	default:
+		return "", nil, false
+	}
+}
+
+// emitLifecycleEvent publishes model's event for eventType through pub,
+// if model opts in via Publishable. Errors are swallowed into a single
+// returned error so hook call sites can choose whether to treat a
+// publish failure as fatal.
+func emitLifecycleEvent(pub EventPublisher, eventType string, model interface{}) error {
+	if pub == nil {
+		return nil
+	}
+	publishable, ok := model.(Publishable)
+	if !ok {
+		return nil
+	}
+	subject, payload, ok := publishable.ToEvent(eventType)
+	if !ok {
+		return nil
+	}
+	return pub.Publish(subject, payload)
+}
+
+// ---- NATS implementation ---------------------------------------------------
+
+// NATSPublishConn is the minimal subset of a nats.Conn a publisher
+// needs, kept as an interface so callers can plug in the real client.
+// It's separate from NATSConn (repository.go) because Publish is
+// fire-and-forget while Request blocks for a reply.
+type NATSPublishConn interface {
+	NATSConn
+	Publish(subject string, data []byte) error
+}
+
+// RetryConfig controls the exponential backoff natsEventPublisher uses
+// when a publish attempt fails.
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryConfig gives at-least-once delivery a reasonable number
+// of attempts without blocking the caller for too long.
+var DefaultRetryConfig = RetryConfig{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// natsEventPublisher publishes domain events over NATS under a
+// configurable subject prefix, retrying failed publishes with
+// exponential backoff for at-least-once delivery.
+type natsEventPublisher struct {
+	conn    NATSPublishConn
+	prefix  string
+	retry   RetryConfig
+	timeout time.Duration
+}
+
+// NewNATSEventPublisher returns an EventPublisher that publishes to
+// "<prefix>.<subject>" over conn.
+func NewNATSEventPublisher(conn NATSPublishConn, prefix string) EventPublisher {
+	return &natsEventPublisher{conn: conn, prefix: prefix, retry: DefaultRetryConfig, timeout: 5 * time.Second}
+}
+
+func (p *natsEventPublisher) fullSubject(subject string) string {
+	if p.prefix == "" {
+		return subject
+	}
+	return strings.TrimSuffix(p.prefix, ".") + "." + subject
+}
+
+func (p *natsEventPublisher) Publish(subject string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	full := p.fullSubject(subject)
+	var lastErr error
+	for attempt := 0; attempt < p.retry.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffDelay(p.retry, attempt))
+		}
+		if lastErr = p.conn.Publish(full, body); lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("events: publish %s failed after %d attempts: %w", full, p.retry.MaxAttempts, lastErr)
+}
+
+func (p *natsEventPublisher) Request(subject string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.conn.Request(p.fullSubject(subject), body, p.timeout)
+	if err != nil {
+		return fmt.Errorf("events: request %s: %w", p.fullSubject(subject), err)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp, out)
+}
+
+func backoffDelay(cfg RetryConfig, attempt int) time.Duration {
+	delay := cfg.BaseDelay * time.Duration(math.Pow(2, float64(attempt-1)))
+	if delay > cfg.MaxDelay {
+		return cfg.MaxDelay
+	}
+	return delay
+}
+
+// defaultEventPublisher is the process-wide publisher model hooks use.
+// It defaults to an in-memory bus so the app runs without a NATS
+// dependency; call SetDefaultEventPublisher(NewNATSEventPublisher(...))
+// during startup to publish over NATS instead.
+var defaultEventPublisher EventPublisher = NewMemoryEventBus()
+
+// SetDefaultEventPublisher replaces the publisher model lifecycle hooks
+// use.
+func SetDefaultEventPublisher(pub EventPublisher) {
+	defaultEventPublisher = pub
+}
+
+// ---- In-memory implementation ---------------------------------------------
+
+// memoryEventBus is a synchronous, in-process EventPublisher for tests:
+// Publish calls every subscriber immediately, and Request calls the
+// subject's replier (the one most recently registered with Reply) and
+// returns its result.
+type memoryEventBus struct {
+	subscribers map[string][]func(payload json.RawMessage)
+	repliers    map[string]func(payload json.RawMessage) (interface{}, error)
+}
+
+// NewMemoryEventBus returns a synchronous in-memory EventPublisher.
+func NewMemoryEventBus() *memoryEventBus {
+	return &memoryEventBus{
+		subscribers: map[string][]func(payload json.RawMessage){},
+		repliers:    map[string]func(payload json.RawMessage) (interface{}, error){},
+	}
+}
+
+// Subscribe registers handler to be called synchronously, in Publish,
+// every time subject is published.
+func (b *memoryEventBus) Subscribe(subject string, handler func(payload json.RawMessage)) {
+	b.subscribers[subject] = append(b.subscribers[subject], handler)
+}
+
+// Reply registers the handler that answers Request calls for subject.
+func (b *memoryEventBus) Reply(subject string, handler func(payload json.RawMessage) (interface{}, error)) {
+	b.repliers[subject] = handler
+}
+
+func (b *memoryEventBus) Publish(subject string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	for _, handler := range b.subscribers[subject] {
+		handler(body)
+	}
+	return nil
+}
+
+func (b *memoryEventBus) Request(subject string, payload interface{}, out interface{}) error {
+	handler, ok := b.repliers[subject]
+	if !ok {
+		return fmt.Errorf("events: no replier registered for %q", subject)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	result, err := handler(body)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	resultBody, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resultBody, out)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/rpc"
+	"os/signal"
+	"syscall"
+)
+
+// This package would normally be generated from .proto files and served
+// via google.golang.org/grpc. Without that dependency available here, it
+// adapts the same service interfaces onto net/rpc (stdlib) instead, so
+// the REST handlers and this second transport still share one set of
+// business logic and main() can still run both concurrently on separate
+// ports with a shared graceful shutdown path.
+
+// UserServiceServer adapts UserService to net/rpc.
+type UserServiceServer struct {
+	service UserService
+}
+
+// NewUserServiceServer wraps service for RPC registration.
+func NewUserServiceServer(service UserService) *UserServiceServer {
+	return &UserServiceServer{service: service}
+}
+
+// GetByID is the RPC-callable equivalent of UserService.GetUserByID.
+func (s *UserServiceServer) GetByID(id int, reply *User) error {
+	user, err := s.service.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	*reply = *user
+	return nil
+}
+
+// Create is the RPC-callable equivalent of UserService.CreateUser.
+func (s *UserServiceServer) Create(user User, reply *User) error {
+	if err := s.service.CreateUser(&user); err != nil {
+		return err
+	}
+	*reply = user
+	return nil
+}
+
+// ProductServiceServer adapts ProductService to net/rpc.
+type ProductServiceServer struct {
+	service ProductService
+}
+
+// NewProductServiceServer wraps service for RPC registration.
+func NewProductServiceServer(service ProductService) *ProductServiceServer {
+	return &ProductServiceServer{service: service}
+}
+
+// GetByID is the RPC-callable equivalent of ProductService.GetProductByID.
+func (s *ProductServiceServer) GetByID(id int, reply *Product) error {
+	product, err := s.service.GetProductByID(id)
+	if err != nil {
+		return err
+	}
+	*reply = *product
+	return nil
+}
+
+// OrderServiceServer adapts OrderService to net/rpc.
+type OrderServiceServer struct {
+	service OrderService
+}
+
+// NewOrderServiceServer wraps service for RPC registration.
+func NewOrderServiceServer(service OrderService) *OrderServiceServer {
+	return &OrderServiceServer{service: service}
+}
+
+// GetByID is the RPC-callable equivalent of OrderService.GetOrderByID.
+func (s *OrderServiceServer) GetByID(id int, reply *Order) error {
+	order, err := s.service.GetOrderByID(id)
+	if err != nil {
+		return err
+	}
+	*reply = *order
+	return nil
+}
+
+// Create is the RPC-callable equivalent of OrderService.CreateOrder.
+func (s *OrderServiceServer) Create(order Order, reply *Order) error {
+	if err := s.service.CreateOrder(&order); err != nil {
+		return err
+	}
+	*reply = order
+	return nil
+}
+
+// AuthServiceServer adapts AuthService to net/rpc.
+type AuthServiceServer struct {
+	service AuthService
+}
+
+// NewAuthServiceServer wraps service for RPC registration.
+func NewAuthServiceServer(service AuthService) *AuthServiceServer {
+	return &AuthServiceServer{service: service}
+}
+
+// LoginArgs carries the Login RPC's parameters.
+type LoginArgs struct {
+	Username string
+	Password string
+}
+
+// Login is the RPC-callable equivalent of AuthService.Login.
+func (s *AuthServiceServer) Login(args LoginArgs, reply *string) error {
+	token, err := s.service.Login(args.Username, args.Password)
+	if err != nil {
+		return err
+	}
+	*reply = token
+	return nil
+}
+
+// RPCServer hosts the adapted services on a TCP listener, standing in
+// for the gRPC server grpc.NewServer() would otherwise provide.
+type RPCServer struct {
+	server   *rpc.Server
+	listener net.Listener
+}
+
+// NewRPCServer registers every adapted service and binds addr (e.g.
+// ":9090").
+func NewRPCServer(addr string, users *UserServiceServer, products *ProductServiceServer, orders *OrderServiceServer, auth *AuthServiceServer) (*RPCServer, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("UserService", users); err != nil {
+		return nil, err
+	}
+	if err := server.RegisterName("ProductService", products); err != nil {
+		return nil, err
+	}
+	if err := server.RegisterName("OrderService", orders); err != nil {
+		return nil, err
+	}
+	if err := server.RegisterName("AuthService", auth); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &RPCServer{server: server, listener: listener}, nil
+}
+
+// Serve accepts connections until the listener is closed (by Shutdown).
+func (s *RPCServer) Serve() error {
+	s.server.Accept(s.listener)
+	return nil
+}
+
+// Shutdown closes the listener, causing Serve to return.
+func (s *RPCServer) Shutdown() error {
+	return s.listener.Close()
+}
+
+// RunServers starts httpServer and rpcServer concurrently and blocks
+// until ctx is cancelled (e.g. by SIGINT/SIGTERM), then shuts both down.
+func RunServers(ctx context.Context, httpServer *http.Server, rpcServer *RPCServer) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 2)
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errs <- err
+		}
+	}()
+	go func() {
+		errs <- rpcServer.Serve()
+	}()
+
+	select {
+	case <-ctx.Done():
+		httpServer.Shutdown(context.Background())
+		rpcServer.Shutdown()
+		return nil
+	case err := <-errs:
+		httpServer.Shutdown(context.Background())
+		rpcServer.Shutdown()
+		return err
+	}
+}
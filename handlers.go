@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -38,7 +40,7 @@ func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 
 // GetUser handles GET /users/{id}
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/users/"):]
+	idStr := URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
@@ -64,8 +66,12 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 // CreateUser handles POST /users
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var user User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := requestBinder.Bind(r, &user); err != nil {
+		e := bindDecodeError(err)
+		WriteHTTPError(w, http.StatusBadRequest, e.Code, e.Message, e.Details)
+		return
+	}
+	if !ValidateRequest(w, &user) {
 		return
 	}
 
@@ -74,20 +80,16 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := APIResponse{
+	Render(w, r, http.StatusCreated, APIResponse{
 		Success: true,
 		Data:    user,
 		Code:    http.StatusCreated,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 // UpdateUser handles PUT /users/{id}
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/users/"):]
+	idStr := URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
@@ -95,30 +97,31 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var user User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := requestBinder.Bind(r, &user); err != nil {
+		e := bindDecodeError(err)
+		WriteHTTPError(w, http.StatusBadRequest, e.Code, e.Message, e.Details)
 		return
 	}
 	user.ID = id
+	if !ValidateRequest(w, &user) {
+		return
+	}
 
 	if err := h.userService.UpdateUser(&user); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	response := APIResponse{
+	Render(w, r, http.StatusOK, APIResponse{
 		Success: true,
 		Data:    user,
 		Code:    http.StatusOK,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 // DeleteUser handles DELETE /users/{id}
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/users/"):]
+	idStr := URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
@@ -170,7 +173,7 @@ func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 
 // GetProduct handles GET /products/{id}
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/products/"):]
+	idStr := URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		http.Error(w, "Invalid product ID", http.StatusBadRequest)
@@ -206,8 +209,12 @@ func NewOrderHandler(service OrderService) *OrderHandler {
 // CreateOrder handles POST /orders
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var order Order
-	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := requestBinder.Bind(r, &order); err != nil {
+		e := bindDecodeError(err)
+		WriteHTTPError(w, http.StatusBadRequest, e.Code, e.Message, e.Details)
+		return
+	}
+	if !ValidateRequest(w, &order) {
 		return
 	}
 
@@ -216,20 +223,16 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := APIResponse{
+	Render(w, r, http.StatusCreated, APIResponse{
 		Success: true,
 		Data:    order,
 		Code:    http.StatusCreated,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 // GetOrder handles GET /orders/{id}
 func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/orders/"):]
+	idStr := URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		http.Error(w, "Invalid order ID", http.StatusBadRequest)
@@ -269,8 +272,9 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		Password string `json:"password"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := requestBinder.Bind(r, &credentials); err != nil {
+		e := bindDecodeError(err)
+		WriteHTTPError(w, http.StatusBadRequest, e.Code, e.Message, e.Details)
 		return
 	}
 
@@ -280,21 +284,29 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := APIResponse{
+	if session, ok := SessionFromContext(r.Context()); ok {
+		if user, err := h.authService.ValidateToken(token); err == nil {
+			session.Set(sessionKeyUserID, user.ID)
+			session.Set(sessionKeyRoles, user.Role)
+		}
+	}
+
+	Render(w, r, http.StatusOK, APIResponse{
 		Success: true,
 		Data:    map[string]string{"token": token},
 		Code:    http.StatusOK,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 // Register handles POST /register
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var user User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := requestBinder.Bind(r, &user); err != nil {
+		e := bindDecodeError(err)
+		WriteHTTPError(w, http.StatusBadRequest, e.Code, e.Message, e.Details)
+		return
+	}
+	if !ValidateRequest(w, &user) {
 		return
 	}
 
@@ -303,15 +315,11 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := APIResponse{
+	Render(w, r, http.StatusCreated, APIResponse{
 		Success: true,
 		Data:    user,
 		Code:    http.StatusCreated,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 // Middleware functions
@@ -325,18 +333,30 @@ func LoggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if token == "" {
-			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
-			return
+// AuthMiddleware validates JWT access tokens against cfg, then places the
+// resulting *User and granted Scope into r.Context() for downstream
+// handlers (read back via RequireAuth/RequireRole).
+func AuthMiddleware(cfg AuthConfig) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+				return
+			}
+			token := strings.TrimPrefix(header, "Bearer ")
+
+			claims, err := ParseJWT(token, cfg)
+			if err != nil {
+				http.Error(w, "Invalid authorization token: "+err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			user := &User{Email: claims.Email, Role: claims.Role}
+			ctx := context.WithValue(r.Context(), userContextKey, user)
+			ctx = context.WithValue(ctx, scopeContextKey, ParseScope(claims.Scope))
+			next(w, r.WithContext(ctx))
 		}
-
-		// Validate token logic would go here
-		// For now, just pass through
-		next(w, r)
 	}
 }
 
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WarehouseModel represents a physical or logical stock location.
+type WarehouseModel struct {
+	BaseModel
+	Name     string `json:"name" gorm:"not null"`
+	Code     string `json:"code" gorm:"uniqueIndex;not null"`
+	Address  string `json:"address"`
+	IsActive bool   `json:"is_active" gorm:"default:true"`
+}
+
+// StockLotModel is one FIFO batch of stock received into a warehouse.
+// InventoryService.Pick consumes lots oldest-ReceivedAt-first.
+type StockLotModel struct {
+	BaseModel
+	ProductID         uint       `json:"product_id" gorm:"not null"`
+	VariantID         *uint      `json:"variant_id"`
+	WarehouseID       uint       `json:"warehouse_id" gorm:"not null"`
+	QuantityRemaining int        `json:"quantity_remaining" gorm:"not null"`
+	UnitCost          float64    `json:"unit_cost" gorm:"type:decimal(10,2);not null"`
+	ReceivedAt        time.Time  `json:"received_at"`
+	ExpiryAt          *time.Time `json:"expiry_at"`
+}
+
+// StockMovementType classifies a StockMovementModel entry.
+type StockMovementType string
+
+const (
+	MovementIn      StockMovementType = "in"
+	MovementOut     StockMovementType = "out"
+	MovementReserve StockMovementType = "reserve"
+	MovementRelease StockMovementType = "release"
+	MovementAdjust  StockMovementType = "adjust"
+)
+
+// StockMovementModel is an append-only ledger entry against a lot.
+type StockMovementModel struct {
+	BaseModel
+	LotID      uint              `json:"lot_id" gorm:"not null"`
+	Type       StockMovementType `json:"type" gorm:"type:varchar(20);not null"`
+	Qty        int               `json:"qty" gorm:"not null"`
+	RefOrderID *uint             `json:"ref_order_id"`
+}
+
+// StockReservationModel holds stock reserved for a cart item until the
+// cart checks out (Commit) or the reservation is released or expires.
+type StockReservationModel struct {
+	BaseModel
+	CartItemID  *uint     `json:"cart_item_id"`
+	OrderItemID *uint     `json:"order_item_id"`
+	ProductID   uint      `json:"product_id" gorm:"not null"`
+	VariantID   *uint     `json:"variant_id"`
+	LotID       uint      `json:"lot_id" gorm:"not null"`
+	Qty         int       `json:"qty" gorm:"not null"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Released    bool      `json:"released" gorm:"default:false"`
+}
+
+// ReservationItem is one line of a Reserve() request.
+type ReservationItem struct {
+	ProductID uint
+	VariantID *uint
+	Qty       int
+}
+
+// InventoryStore is the persistence surface InventoryService needs over
+// lots, movements, and reservations.
+type InventoryStore interface {
+	LotsFIFO(productID uint, variantID *uint) ([]StockLotModel, error)
+	SaveLot(lot StockLotModel) error
+	SaveMovement(movement StockMovementModel) error
+	SaveReservation(reservation StockReservationModel) error
+	ReservationsForCart(cartID uint) ([]StockReservationModel, error)
+	ExpiredReservations(now time.Time) ([]StockReservationModel, error)
+	// SaveProductStock persists the recomputed denormalized aggregate:
+	// ProductModel.StockQuantity when variantID is nil, otherwise the
+	// matching ProductVariant.StockQuantity.
+	SaveProductStock(productID uint, variantID *uint, qty int) error
+}
+
+// InventoryService reserves, commits, and releases stock against the
+// FIFO lot ledger.
+type InventoryService struct {
+	store InventoryStore
+}
+
+// NewInventoryService returns an InventoryService backed by store.
+func NewInventoryService(store InventoryStore) *InventoryService {
+	return &InventoryService{store: store}
+}
+
+// Pick consumes qty units of productID/variantID from the oldest lots
+// first, returning the total cost of goods sold and the lots drawn from
+// (with QuantityRemaining already decremented).
+func (s *InventoryService) Pick(productID uint, variantID *uint, qty int) (costOfGoods float64, lots []StockLotModel, err error) {
+	available, err := s.store.LotsFIFO(productID, variantID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	remaining := qty
+	for i := range available {
+		if remaining <= 0 {
+			break
+		}
+		lot := &available[i]
+		take := lot.QuantityRemaining
+		if take > remaining {
+			take = remaining
+		}
+		if take <= 0 {
+			continue
+		}
+		lot.QuantityRemaining -= take
+		remaining -= take
+		costOfGoods += float64(take) * lot.UnitCost
+		lots = append(lots, *lot)
+
+		if err := s.store.SaveLot(*lot); err != nil {
+			return 0, nil, err
+		}
+		if err := s.store.SaveMovement(StockMovementModel{LotID: lot.ID, Type: MovementOut, Qty: take}); err != nil {
+			return 0, nil, err
+		}
+	}
+	if remaining > 0 {
+		return 0, nil, fmt.Errorf("inventory: insufficient stock for product %d (short by %d)", productID, remaining)
+	}
+
+	remainingTotal := sumRemaining(available)
+	if err := s.store.SaveProductStock(productID, variantID, remainingTotal); err != nil {
+		return 0, nil, err
+	}
+	checkStockLow(productID, remainingTotal)
+	return costOfGoods, lots, nil
+}
+
+// syncProductStock recomputes the aggregate QuantityRemaining across
+// every lot for productID/variantID and persists it as the
+// ProductModel/ProductVariant StockQuantity column, keeping that
+// denormalized field in step with the ledger.
+func (s *InventoryService) syncProductStock(productID uint, variantID *uint) error {
+	lots, err := s.store.LotsFIFO(productID, variantID)
+	if err != nil {
+		return err
+	}
+	return s.store.SaveProductStock(productID, variantID, sumRemaining(lots))
+}
+
+// lowStockThreshold is the aggregate quantity at or below which
+// checkStockLow publishes "product.stock_low".
+const lowStockThreshold = 10
+
+func sumRemaining(lots []StockLotModel) int {
+	total := 0
+	for _, lot := range lots {
+		total += lot.QuantityRemaining
+	}
+	return total
+}
+
+func checkStockLow(productID uint, remaining int) {
+	if remaining > lowStockThreshold {
+		return
+	}
+	_ = defaultEventPublisher.Publish("product.stock_low", ProductStockLowEvent{
+		ProductID:     productID,
+		StockQuantity: remaining,
+		Threshold:     lowStockThreshold,
+	})
+}
+
+// Reserve holds stock for each item in a cart without yet consuming it
+// as a sale, expiring at expiresAt (normally CartModel.ExpiresAt).
+func (s *InventoryService) Reserve(cartID uint, items []ReservationItem, expiresAt time.Time) error {
+	for _, item := range items {
+		lots, err := s.store.LotsFIFO(item.ProductID, item.VariantID)
+		if err != nil {
+			return err
+		}
+		remaining := item.Qty
+		for _, lot := range lots {
+			if remaining <= 0 {
+				break
+			}
+			take := lot.QuantityRemaining
+			if take > remaining {
+				take = remaining
+			}
+			if take <= 0 {
+				continue
+			}
+			lot.QuantityRemaining -= take
+			remaining -= take
+			if err := s.store.SaveLot(lot); err != nil {
+				return err
+			}
+			if err := s.store.SaveMovement(StockMovementModel{LotID: lot.ID, Type: MovementReserve, Qty: take}); err != nil {
+				return err
+			}
+			if err := s.store.SaveReservation(StockReservationModel{
+				CartItemID: &cartID,
+				ProductID:  item.ProductID,
+				VariantID:  item.VariantID,
+				LotID:      lot.ID,
+				Qty:        take,
+				ExpiresAt:  expiresAt,
+			}); err != nil {
+				return err
+			}
+		}
+		if remaining > 0 {
+			return fmt.Errorf("inventory: insufficient stock to reserve product %d (short by %d)", item.ProductID, remaining)
+		}
+		if err := s.syncProductStock(item.ProductID, item.VariantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release returns every not-yet-released reservation for cartID back to
+// its lot's QuantityRemaining, recording a release movement for each.
+// Expiry is not checked here.
+func (s *InventoryService) Release(cartID uint) error {
+	reservations, err := s.store.ReservationsForCart(cartID)
+	if err != nil {
+		return err
+	}
+	return s.releaseAll(reservations)
+}
+
+func (s *InventoryService) releaseAll(reservations []StockReservationModel) error {
+	for _, r := range reservations {
+		if r.Released {
+			continue
+		}
+		lots, err := s.store.LotsFIFO(r.ProductID, r.VariantID)
+		if err != nil {
+			return err
+		}
+		for _, lot := range lots {
+			if lot.ID != r.LotID {
+				continue
+			}
+			lot.QuantityRemaining += r.Qty
+			if err := s.store.SaveLot(lot); err != nil {
+				return err
+			}
+			if err := s.store.SaveMovement(StockMovementModel{LotID: lot.ID, Type: MovementRelease, Qty: r.Qty}); err != nil {
+				return err
+			}
+			break
+		}
+		r.Released = true
+		if err := s.store.SaveReservation(r); err != nil {
+			return err
+		}
+		if err := s.syncProductStock(r.ProductID, r.VariantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit converts a cart's reservations into a permanent sale against
+// orderID: reserved quantity is consumed (not returned to the lot), and
+// a cost-of-goods figure is returned for accounting.
+func (s *InventoryService) Commit(cartID, orderID uint) (costOfGoods float64, err error) {
+	reservations, err := s.store.ReservationsForCart(cartID)
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range reservations {
+		if r.Released {
+			continue
+		}
+		lots, err := s.store.LotsFIFO(r.ProductID, r.VariantID)
+		if err != nil {
+			return 0, err
+		}
+		for _, lot := range lots {
+			if lot.ID != r.LotID {
+				continue
+			}
+			costOfGoods += float64(r.Qty) * lot.UnitCost
+			oid := orderID
+			if err := s.store.SaveMovement(StockMovementModel{LotID: lot.ID, Type: MovementOut, Qty: r.Qty, RefOrderID: &oid}); err != nil {
+				return 0, err
+			}
+			break
+		}
+		r.Released = true
+		if err := s.store.SaveReservation(r); err != nil {
+			return 0, err
+		}
+	}
+	return costOfGoods, nil
+}
+
+// SweepExpired releases every reservation whose ExpiresAt has passed.
+// Intended to run on a ticker from a background goroutine.
+func (s *InventoryService) SweepExpired(now time.Time) error {
+	expired, err := s.store.ExpiredReservations(now)
+	if err != nil {
+		return err
+	}
+	return s.releaseAll(expired)
+}
+
+// RunReservationSweeper releases expired reservations every interval
+// until ctx is cancelled.
+func RunReservationSweeper(ctx context.Context, svc *InventoryService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			_ = svc.SweepExpired(now)
+		}
+	}
+}
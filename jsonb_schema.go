@@ -0,0 +1,209 @@
+package main
+
+import "fmt"
+
+// JSONSchema is a small subset of JSON Schema draft-07 — enough to
+// constrain the shape of a JSONB column without pulling in a full
+// validator: object/string/number/boolean types, required properties,
+// enums, and additionalProperties.
+type JSONSchema struct {
+	Type                 string                `json:"type"`
+	Properties           map[string]JSONSchema `json:"properties,omitempty"`
+	Required             []string              `json:"required,omitempty"`
+	Enum                 []string              `json:"enum,omitempty"`
+	AdditionalProperties *bool                 `json:"additionalProperties,omitempty"`
+}
+
+// JSONBValidationError reports every field path in a JSONB payload that
+// failed schema validation.
+type JSONBValidationError struct {
+	Table  string
+	Column string
+	Fields []FieldError
+}
+
+// FieldError names one schema violation within a JSONB payload.
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e *JSONBValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return fmt.Sprintf("jsonb_schema: %s.%s failed validation", e.Table, e.Column)
+	}
+	return fmt.Sprintf("jsonb_schema: %s.%s: %s: %s", e.Table, e.Column, e.Fields[0].Path, e.Fields[0].Message)
+}
+
+// StrictMode rejects unknown object keys for any schema that doesn't
+// explicitly set AdditionalProperties. Off by default so existing data
+// with extra keys keeps validating during rollout.
+var StrictMode = false
+
+type schemaKey struct {
+	table, column string
+}
+
+// SchemaRegistry maps (table, column) pairs to the JSONSchema their
+// JSONB payload must satisfy.
+type SchemaRegistry struct {
+	schemas map[schemaKey]JSONSchema
+}
+
+// NewSchemaRegistry returns an empty SchemaRegistry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: map[schemaKey]JSONSchema{}}
+}
+
+// RegisterSchema registers schema for table.column, returning an error
+// if one's already registered for that pair.
+func (r *SchemaRegistry) RegisterSchema(table, column string, schema JSONSchema) error {
+	key := schemaKey{table, column}
+	if _, exists := r.schemas[key]; exists {
+		return fmt.Errorf("jsonb_schema: a schema is already registered for %s.%s", table, column)
+	}
+	r.schemas[key] = schema
+	return nil
+}
+
+// MustRegisterSchema registers schema for table.column, panicking if
+// one's already registered. Intended for package-level init().
+func (r *SchemaRegistry) MustRegisterSchema(table, column string, schema JSONSchema) {
+	if err := r.RegisterSchema(table, column, schema); err != nil {
+		panic(err)
+	}
+}
+
+// Validate checks value against the schema registered for table.column.
+// If no schema is registered, the payload passes unchecked.
+func (r *SchemaRegistry) Validate(table, column string, value JSONB) error {
+	schema, ok := r.schemas[schemaKey{table, column}]
+	if !ok {
+		return nil
+	}
+
+	var fields []FieldError
+	validateNode(schema, value, column, &fields)
+	if len(fields) > 0 {
+		return &JSONBValidationError{Table: table, Column: column, Fields: fields}
+	}
+	return nil
+}
+
+func validateNode(schema JSONSchema, value interface{}, path string, fields *[]FieldError) {
+	if value == nil {
+		return
+	}
+
+	switch schema.Type {
+	case "object", "":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			*fields = append(*fields, FieldError{Path: path, Message: "expected an object"})
+			return
+		}
+		for _, req := range schema.Required {
+			if _, present := obj[req]; !present {
+				*fields = append(*fields, FieldError{Path: path + "." + req, Message: "is required"})
+			}
+		}
+		for key, val := range obj {
+			propSchema, known := schema.Properties[key]
+			if !known {
+				if strictModeFor(schema) {
+					*fields = append(*fields, FieldError{Path: path + "." + key, Message: "is not an allowed property"})
+				}
+				continue
+			}
+			validateNode(propSchema, val, path+"."+key, fields)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			*fields = append(*fields, FieldError{Path: path, Message: "expected a string"})
+			return
+		}
+		if len(schema.Enum) > 0 && !stringInSlice(str, schema.Enum) {
+			*fields = append(*fields, FieldError{Path: path, Message: fmt.Sprintf("must be one of %v", schema.Enum)})
+		}
+	case "number":
+		switch value.(type) {
+		case float64, int, int64:
+		default:
+			*fields = append(*fields, FieldError{Path: path, Message: "expected a number"})
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*fields = append(*fields, FieldError{Path: path, Message: "expected a boolean"})
+		}
+	}
+}
+
+func strictModeFor(schema JSONSchema) bool {
+	if schema.AdditionalProperties != nil {
+		return !*schema.AdditionalProperties
+	}
+	return StrictMode
+}
+
+// defaultSchemaRegistry holds the built-in schemas for the JSONB
+// columns shipped with this repo. BeforeSave hooks validate against it;
+// callers register additional schemas with
+// defaultSchemaRegistry.MustRegisterSchema for their own columns.
+var defaultSchemaRegistry = NewSchemaRegistry()
+
+func init() {
+	notFalse := false
+
+	defaultSchemaRegistry.MustRegisterSchema("products", "dimensions", JSONSchema{
+		Type:     "object",
+		Required: []string{"length", "width", "height", "unit"},
+		Properties: map[string]JSONSchema{
+			"length": {Type: "number"},
+			"width":  {Type: "number"},
+			"height": {Type: "number"},
+			"unit":   {Type: "string", Enum: []string{"cm", "in"}},
+		},
+		AdditionalProperties: &notFalse,
+	})
+
+	defaultSchemaRegistry.MustRegisterSchema("user_profiles", "social_links", JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"twitter":   {Type: "string"},
+			"facebook":  {Type: "string"},
+			"instagram": {Type: "string"},
+			"linkedin":  {Type: "string"},
+			"github":    {Type: "string"},
+			"youtube":   {Type: "string"},
+		},
+	})
+
+	defaultSchemaRegistry.MustRegisterSchema("product_variants", "attributes", JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"color":    {Type: "string"},
+			"size":     {Type: "string", Enum: []string{"xs", "s", "m", "l", "xl", "xxl"}},
+			"material": {Type: "string"},
+		},
+	})
+}
+
+// BeforeSave validates SocialLinks against the registered schema. The
+// database/sql.Scanner/Valuer interfaces JSONB implements (Scan/Value)
+// have no way to know which table/column they're serializing for, so
+// schema enforcement happens here instead, where that context is
+// available.
+func (p *UserProfile) BeforeSave() error {
+	return defaultSchemaRegistry.Validate("user_profiles", "social_links", p.SocialLinks)
+}
+
+// BeforeSave validates Dimensions against the registered schema.
+func (p *ProductModel) BeforeSave() error {
+	return defaultSchemaRegistry.Validate("products", "dimensions", p.Dimensions)
+}
+
+// BeforeSave validates Attributes against the registered schema.
+func (v *ProductVariant) BeforeSave() error {
+	return defaultSchemaRegistry.Validate("product_variants", "attributes", v.Attributes)
+}
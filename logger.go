@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// logLevel orders the four levels Logger supports so filtering can
+// compare a configured minimum against an emitted level.
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+func parseLevel(s string) logLevel {
+	switch s {
+	case "debug":
+		return levelDebug
+	case "warn":
+		return levelWarn
+	case "error":
+		return levelError
+	default:
+		return levelInfo
+	}
+}
+
+func (l logLevel) String() string {
+	switch l {
+	case levelDebug:
+		return "debug"
+	case levelWarn:
+		return "warn"
+	case levelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// requestIDKey is the context key WithContext looks for when attaching a
+// request ID to every subsequent log line.
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying id for loggers created via
+// Logger.WithContext.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// Hook lets integrations subscribe to log events, e.g. to page on Error.
+type Hook interface {
+	Fire(level string, msg string, fields map[string]interface{})
+}
+
+// sink writes one already-formatted log line.
+type sink interface {
+	Write(line []byte) error
+}
+
+type stdoutSink struct{}
+
+func (stdoutSink) Write(line []byte) error {
+	_, err := os.Stdout.Write(line)
+	return err
+}
+
+// fileSink appends to a file, rotating it once it grows past maxSizeMB.
+// Age-based rotation (maxAgeDays) is enforced by comparing the time the
+// sink opened (or last rotated) the file on each rotation check.
+type fileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxSizeMB  int
+	maxAgeDays int
+	file       *os.File
+	opened     time.Time
+}
+
+func newFileSink(path string, maxSizeMB, maxAgeDays int) (*fileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{path: path, maxSizeMB: maxSizeMB, maxAgeDays: maxAgeDays, file: f, opened: time.Now()}, nil
+}
+
+func (s *fileSink) Write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shouldRotate() {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	_, err := s.file.Write(line)
+	return err
+}
+
+func (s *fileSink) shouldRotate() bool {
+	if s.maxAgeDays > 0 && time.Since(s.opened) > time.Duration(s.maxAgeDays)*24*time.Hour {
+		return true
+	}
+	if s.maxSizeMB <= 0 {
+		return false
+	}
+	info, err := s.file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Size() >= int64(s.maxSizeMB)*1024*1024
+}
+
+func (s *fileSink) rotate() error {
+	s.file.Close()
+	rotated := fmt.Sprintf("%s.%d", s.path, time.Now().Unix())
+	if err := os.Rename(s.path, rotated); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	s.file = f
+	s.opened = time.Now()
+	return nil
+}
+
+// webhookSink POSTs each log line to an HTTP endpoint, e.g. an alerting
+// integration.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Write(line []byte) error {
+	resp, err := s.client.Post(s.url, "application/json", jsonReader(line))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return nil
+}
+
+func jsonReader(b []byte) *bytesReader { return &bytesReader{b: b} }
+
+// bytesReader is a minimal io.Reader over a byte slice, avoiding a
+// bytes.NewReader import purely to keep this file's import list tight.
+type bytesReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *bytesReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.b) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.b[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+// logger is the default Logger implementation: level-filtered, JSON or
+// text encoded, fanned out to one or more sinks, with optional sampling
+// and hook notification on every emitted line.
+type logger struct {
+	level    logLevel
+	format   string
+	sinks    []sink
+	hooks    []Hook
+	sample   int
+	counter  int
+	mu       sync.Mutex
+	ctx      context.Context
+	baseArgs map[string]interface{}
+}
+
+// NewLogger builds a Logger from cfg, wiring either stdout or (when
+// cfg.Output is "file") a rotating file, plus an optional webhook sink.
+func NewLogger(cfg LogConfig) Logger {
+	l := &logger{
+		level:  parseLevel(cfg.Level),
+		format: cfg.Format,
+		ctx:    context.Background(),
+	}
+
+	switch cfg.Output {
+	case "file":
+		if fs, err := newFileSink(cfg.File, cfg.MaxSizeMB, cfg.MaxAgeDays); err == nil {
+			l.sinks = append(l.sinks, fs)
+		}
+	default:
+		l.sinks = append(l.sinks, stdoutSink{})
+	}
+
+	if cfg.WebhookURL != "" {
+		l.sinks = append(l.sinks, &webhookSink{url: cfg.WebhookURL, client: http.DefaultClient})
+	}
+
+	return l
+}
+
+// AddHook registers h to be notified of every subsequent log event.
+func (l *logger) AddHook(h Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, h)
+}
+
+// SetSampleRate keeps roughly 1-in-n log lines at levelInfo and below,
+// for hot paths that would otherwise flood the sinks. n <= 1 disables
+// sampling.
+func (l *logger) SetSampleRate(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sample = n
+}
+
+// WithContext returns a Logger that shares l's sinks, hooks, and config
+// but carries ctx. It copies fields individually rather than `clone :=
+// *l`, since logger embeds a sync.Mutex and copying a struct copies its
+// lock value along with it.
+func (l *logger) WithContext(ctx context.Context) Logger {
+	l.mu.Lock()
+	clone := &logger{
+		level:    l.level,
+		format:   l.format,
+		sinks:    l.sinks,
+		hooks:    l.hooks,
+		sample:   l.sample,
+		counter:  l.counter,
+		ctx:      ctx,
+		baseArgs: l.baseArgs,
+	}
+	l.mu.Unlock()
+	return clone
+}
+
+func (l *logger) Info(msg string, args ...interface{})  { l.log(levelInfo, msg, args) }
+func (l *logger) Error(msg string, args ...interface{}) { l.log(levelError, msg, args) }
+func (l *logger) Debug(msg string, args ...interface{}) { l.log(levelDebug, msg, args) }
+func (l *logger) Warn(msg string, args ...interface{})  { l.log(levelWarn, msg, args) }
+
+func (l *logger) log(level logLevel, msg string, args []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	l.mu.Lock()
+	l.counter++
+	if level <= levelInfo && l.sample > 1 && l.counter%l.sample != 0 {
+		l.mu.Unlock()
+		return
+	}
+	hooks := append([]Hook(nil), l.hooks...)
+	l.mu.Unlock()
+
+	fields := pairArgs(args)
+	if id, ok := l.ctx.Value(requestIDKey{}).(string); ok {
+		fields["request_id"] = id
+	}
+
+	line := l.encode(level, msg, fields)
+	for _, s := range l.sinks {
+		s.Write(line)
+	}
+	for _, h := range hooks {
+		h.Fire(level.String(), msg, fields)
+	}
+}
+
+// pairArgs turns a flat args list of alternating key/value pairs into a
+// fields map, matching how callers pass structured data today.
+func pairArgs(args []interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", args[i])
+		}
+		fields[key] = args[i+1]
+	}
+	return fields
+}
+
+func (l *logger) encode(level logLevel, msg string, fields map[string]interface{}) []byte {
+	if l.format == "json" {
+		entry := map[string]interface{}{
+			"level": level.String(),
+			"msg":   msg,
+			"time":  time.Now().Format(time.RFC3339),
+		}
+		for k, v := range fields {
+			entry[k] = v
+		}
+		b, _ := json.Marshal(entry)
+		return append(b, '\n')
+	}
+
+	line := fmt.Sprintf("%s [%s] %s", time.Now().Format(time.RFC3339), level.String(), msg)
+	for k, v := range fields {
+		line += fmt.Sprintf(" %s=%v", k, v)
+	}
+	return []byte(line + "\n")
+}
+
+// RequestLogger returns middleware that logs method, path, status, and
+// duration for every request, via l.
+func RequestLogger(l Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			l.Info("request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"duration_ms", time.Since(start).Milliseconds(),
+			)
+		})
+	}
+}
+
+// statusRecorder captures the status code written by the wrapped
+// http.ResponseWriter so middleware can log it afterward.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -1,32 +1,92 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 )
 
+// defaultConfig returns the Config main() runs with absent any
+// environment-specific overrides. A real deployment would load this
+// from a file or env vars; these values keep `go run .` usable out of
+// the box against a local Postgres.
+func defaultConfig() Config {
+	return Config{
+		Database: DatabaseConfig{Host: "localhost", Port: 5432, User: "postgres", Password: "postgres", Name: "app", SSLMode: "disable"},
+		Server:   ServerConfig{Host: "", Port: 8080, Env: "development"},
+		Auth:     AuthConfig{JWTSecret: "dev-secret-change-me", JWTExpiryHour: 24, BcryptCost: 12},
+		Log:      LogConfig{Level: "info", Output: "stdout"},
+	}
+}
+
 // This is the main entry point for the Go application
 func main() {
 	fmt.Println("Starting Go application...")
 
-	// Initialize server
-	server := &http.Server{
-		Addr:         ":8080",
+	cfg := defaultConfig()
+	logger := NewLogger(cfg.Log)
+
+	repoCfg := RepositoryConfig{Backend: "sql", Database: cfg.Database}
+	userRepo, err := NewRepository(repoCfg, "user")
+	if err != nil {
+		log.Fatalf("failed to build user repository: %v", err)
+	}
+	productRepo, err := NewRepository(repoCfg, "product")
+	if err != nil {
+		log.Fatalf("failed to build product repository: %v", err)
+	}
+	orderRepo, err := NewRepository(repoCfg, "order")
+	if err != nil {
+		log.Fatalf("failed to build order repository: %v", err)
+	}
+
+	userService := NewRepoUserService(userRepo)
+	productService := NewRepoProductService(productRepo)
+	orderService := NewRepoOrderService(orderRepo)
+	authService := NewRepoAuthService(userService, cfg.Auth)
+
+	userHandler := NewUserHandler(userService)
+	productHandler := NewProductHandler(productService)
+	orderHandler := NewOrderHandler(orderService)
+	authHandler := NewAuthHandler(authService)
+
+	router := NewRouter("/api/v1")
+	RegisterRoutes(router, userHandler, productHandler, orderHandler, authHandler, cfg.Auth)
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/", router)
+	mux.HandleFunc("/", homeHandler)
+	mux.HandleFunc("/health", healthHandler)
+
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:      mux,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
 
-	// Setup routes
-	http.HandleFunc("/", homeHandler)
-	http.HandleFunc("/health", healthHandler)
+	rpcServer, err := NewRPCServer(rpcAddr,
+		NewUserServiceServer(userService),
+		NewProductServiceServer(productService),
+		NewOrderServiceServer(orderService),
+		NewAuthServiceServer(authService),
+	)
+	if err != nil {
+		log.Fatalf("failed to build RPC server: %v", err)
+	}
 
-	// Start server
-	fmt.Println("Server starting on port 8080")
-	log.Fatal(server.ListenAndServe())
+	logger.Info("server starting", "http_addr", httpServer.Addr, "rpc_addr", rpcAddr)
+	if err := RunServers(context.Background(), httpServer, rpcServer); err != nil {
+		log.Fatalf("server exited with error: %v", err)
+	}
 }
 
+// rpcAddr is the bind address NewRPCServer listens on for the net/rpc
+// transport that stands in for a gRPC server (see grpcserver.go).
+const rpcAddr = ":9090"
+
 // homeHandler handles the root route
 func homeHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Hello, World!")
@@ -90,7 +90,7 @@ type ProductModel struct {
 	Price           float64         `json:"price" gorm:"type:decimal(10,2);not null"`
 	ComparePrice    float64         `json:"compare_price" gorm:"type:decimal(10,2)"`
 	SKU             string          `json:"sku" gorm:"uniqueIndex"`
-	StockQuantity   int             `json:"stock_quantity" gorm:"default:0"`
+	StockQuantity   int             `json:"stock_quantity" gorm:"default:0"` // denormalized aggregate, recomputed by InventoryService from StockLotModel
 	Weight          float64         `json:"weight" gorm:"type:decimal(8,2)"`
 	Dimensions      JSONB           `json:"dimensions" gorm:"type:jsonb"`
 	CategoryID      uint            `json:"category_id"`
@@ -122,7 +122,7 @@ type ProductVariant struct {
 	Name          string  `json:"name" gorm:"not null"`
 	SKU           string  `json:"sku"`
 	PriceModifier float64 `json:"price_modifier" gorm:"type:decimal(10,2);default:0"`
-	StockQuantity int     `json:"stock_quantity" gorm:"default:0"`
+	StockQuantity int     `json:"stock_quantity" gorm:"default:0"` // denormalized aggregate, recomputed by InventoryService from StockLotModel
 	Attributes    JSONB   `json:"attributes" gorm:"type:jsonb"`
 }
 
@@ -169,6 +169,7 @@ type OrderModel struct {
 	BillingAddress    *AddressModel   `json:"billing_address,omitempty"`
 	PaymentMethod     string          `json:"payment_method"`
 	PaymentStatus     PaymentStatus   `json:"payment_status" gorm:"type:varchar(20);default:'pending'"`
+	AppliedCouponID   *uint           `json:"applied_coupon_id"`
 	Items             []OrderItemModel `json:"items,omitempty" gorm:"foreignKey:OrderID"`
 	Notes             string          `json:"notes" gorm:"type:text"`
 	ShippedAt         *time.Time      `json:"shipped_at"`
@@ -179,13 +180,17 @@ type OrderModel struct {
 type OrderStatus string
 
 const (
-	OrderPending    OrderStatus = "pending"
-	OrderConfirmed  OrderStatus = "confirmed"
-	OrderProcessing OrderStatus = "processing"
-	OrderShipped    OrderStatus = "shipped"
-	OrderDelivered  OrderStatus = "delivered"
-	OrderCancelled  OrderStatus = "cancelled"
-	OrderRefunded   OrderStatus = "refunded"
+	OrderPending          OrderStatus = "pending"
+	OrderConfirmed        OrderStatus = "confirmed"
+	OrderProcessing       OrderStatus = "processing"
+	OrderShipped          OrderStatus = "shipped"
+	OrderDelivered        OrderStatus = "delivered"
+	OrderCancelled        OrderStatus = "cancelled"
+	OrderRefunded         OrderStatus = "refunded"
+	OrderReturnRequested  OrderStatus = "return_requested"
+	OrderReturnApproved   OrderStatus = "return_approved"
+	OrderReturnCancelled  OrderStatus = "return_cancelled"
+	OrderReturned         OrderStatus = "order_returned"
 )
 
 // PaymentStatus represents payment statuses
@@ -238,11 +243,12 @@ type ReviewImage struct {
 // CartModel represents the shopping cart
 type CartModel struct {
 	BaseModel
-	UserID    uint            `json:"user_id" gorm:"not null"`
-	User      UserModel       `json:"user,omitempty"`
-	SessionID string          `json:"session_id"`
-	Items     []CartItemModel `json:"items,omitempty" gorm:"foreignKey:CartID"`
-	ExpiresAt time.Time       `json:"expires_at"`
+	UserID          uint            `json:"user_id" gorm:"not null"`
+	User            UserModel       `json:"user,omitempty"`
+	SessionID       string          `json:"session_id"`
+	Items           []CartItemModel `json:"items,omitempty" gorm:"foreignKey:CartID"`
+	AppliedCouponID *uint           `json:"applied_coupon_id"`
+	ExpiresAt       time.Time       `json:"expires_at"`
 }
 
 // CartItemModel represents cart items
@@ -305,6 +311,24 @@ func (bm *BaseModel) BeforeUpdate() error {
 	return nil
 }
 
+// AfterCreate hook for BaseModel. Models that want to emit a domain
+// event on creation implement Publishable and are dispatched through
+// emitLifecycleEvent by their own After* hook, since BaseModel has no
+// access to the embedding model's concrete type.
+func (bm *BaseModel) AfterCreate() error {
+	return nil
+}
+
+// AfterUpdate hook for BaseModel.
+func (bm *BaseModel) AfterUpdate() error {
+	return nil
+}
+
+// AfterDelete hook for BaseModel.
+func (bm *BaseModel) AfterDelete() error {
+	return nil
+}
+
 // Comment line 301
 // Comment line 302
 // Comment line 303
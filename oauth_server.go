@@ -0,0 +1,579 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientInfo describes a registered OAuth2 client, modelled on go-oauth2's
+// ClientStore.
+type ClientInfo struct {
+	ID           string
+	Secret       string
+	RedirectURIs []string
+	Scopes       []string
+}
+
+// ClientStore looks up registered OAuth2 clients by ID.
+type ClientStore interface {
+	GetByID(id string) (ClientInfo, error)
+}
+
+// memoryClientStore is a ClientStore backed by an in-memory map, useful
+// for tests and single-process deployments.
+type memoryClientStore struct {
+	mu      sync.RWMutex
+	clients map[string]ClientInfo
+}
+
+// NewMemoryClientStore builds a ClientStore seeded with clients.
+func NewMemoryClientStore(clients ...ClientInfo) ClientStore {
+	store := &memoryClientStore{clients: map[string]ClientInfo{}}
+	for _, c := range clients {
+		store.clients[c.ID] = c
+	}
+	return store
+}
+
+func (s *memoryClientStore) GetByID(id string) (ClientInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	client, ok := s.clients[id]
+	if !ok {
+		return ClientInfo{}, fmt.Errorf("oauth: unknown client %q", id)
+	}
+	return client, nil
+}
+
+// Scope is a parsed, space-delimited OAuth2 scope string.
+type Scope []string
+
+// ParseScope splits a space-delimited scope string, e.g. "openid profile
+// admin:users".
+func ParseScope(s string) Scope {
+	return Scope(strings.Fields(s))
+}
+
+// Has reports whether name is among the granted scopes.
+func (s Scope) Has(name string) bool {
+	for _, v := range s {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the scope back to its space-delimited form.
+func (s Scope) String() string {
+	return strings.Join(s, " ")
+}
+
+// FilterByRole drops scopes a role isn't entitled to, e.g. only "admin"
+// may carry "admin:users".
+func (s Scope) FilterByRole(role string) Scope {
+	filtered := make(Scope, 0, len(s))
+	for _, scope := range s {
+		if strings.HasPrefix(scope, "admin:") && role != string(RoleAdmin) {
+			continue
+		}
+		filtered = append(filtered, scope)
+	}
+	return filtered
+}
+
+// authCode is the server-side record of a pending authorization_code
+// grant, including its PKCE challenge.
+type authCode struct {
+	ClientID            string
+	UserID              int
+	Scope               string
+	RedirectURI          string
+	CodeChallenge        string
+	CodeChallengeMethod string
+	ExpiresAt            time.Time
+}
+
+// refreshToken is the server-side record backing a refresh_token grant.
+type refreshToken struct {
+	ClientID string
+	UserID   int
+	Scope    string
+}
+
+// TokenStore persists the short-lived authorization codes and
+// longer-lived refresh tokens the authorization server issues.
+type TokenStore interface {
+	SaveAuthCode(code string, data authCode) error
+	ConsumeAuthCode(code string) (authCode, error)
+	SaveRefreshToken(token string, data refreshToken) error
+	ConsumeRefreshToken(token string) (refreshToken, error)
+}
+
+// memoryTokenStore is an in-memory TokenStore; codes and refresh tokens
+// do not survive a process restart.
+type memoryTokenStore struct {
+	mu     sync.Mutex
+	codes  map[string]authCode
+	tokens map[string]refreshToken
+}
+
+// NewMemoryTokenStore returns an empty in-memory TokenStore.
+func NewMemoryTokenStore() TokenStore {
+	return &memoryTokenStore{codes: map[string]authCode{}, tokens: map[string]refreshToken{}}
+}
+
+func (s *memoryTokenStore) SaveAuthCode(code string, data authCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[code] = data
+	return nil
+}
+
+func (s *memoryTokenStore) ConsumeAuthCode(code string) (authCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.codes[code]
+	if !ok {
+		return authCode{}, errors.New("oauth: unknown or already-used authorization code")
+	}
+	delete(s.codes, code)
+	if time.Now().After(data.ExpiresAt) {
+		return authCode{}, errors.New("oauth: authorization code expired")
+	}
+	return data, nil
+}
+
+func (s *memoryTokenStore) SaveRefreshToken(token string, data refreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = data
+	return nil
+}
+
+func (s *memoryTokenStore) ConsumeRefreshToken(token string) (refreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.tokens[token]
+	if !ok {
+		return refreshToken{}, errors.New("oauth: unknown refresh token")
+	}
+	return data, nil
+}
+
+// AuthorizationServer implements the /authorize, /token,
+// /.well-known/openid-configuration, and /jwks.json endpoints of a
+// minimal OAuth2/OIDC provider.
+type AuthorizationServer struct {
+	clients ClientStore
+	tokens  TokenStore
+	users   UserService
+	auth    AuthService
+	cfg     AuthConfig
+	baseURL string
+}
+
+// NewAuthorizationServer wires an AuthorizationServer from its stores,
+// the AuthService backing the login form an unauthenticated /authorize
+// redirects to, and the AuthConfig used to sign access tokens.
+func NewAuthorizationServer(clients ClientStore, tokens TokenStore, users UserService, auth AuthService, cfg AuthConfig, baseURL string) *AuthorizationServer {
+	return &AuthorizationServer{clients: clients, tokens: tokens, users: users, auth: auth, cfg: cfg, baseURL: baseURL}
+}
+
+// Authorize implements GET/POST /authorize: GET renders a consent page
+// listing the requested scopes; POST (the user's decision) issues an
+// authorization code and redirects back to the client's redirect_uri.
+func (s *AuthorizationServer) Authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	clientID := q.Get("client_id")
+	redirectURI := q.Get("redirect_uri")
+	scope := q.Get("scope")
+	state := q.Get("state")
+	challenge := q.Get("code_challenge")
+	challengeMethod := q.Get("code_challenge_method")
+
+	client, err := s.clients.GetByID(clientID)
+	if err != nil || !stringInSlice(redirectURI, client.RedirectURIs) {
+		http.Error(w, "invalid client_id or redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		session, ok := SessionFromContext(r.Context())
+		if !ok {
+			http.Error(w, "session store unavailable", http.StatusInternalServerError)
+			return
+		}
+		if _, authenticated := sessionUserID(session); !authenticated {
+			session.Set(sessionKeyPendingAuth, r.URL.RawQuery)
+			http.Redirect(w, r, "/authorize/login", http.StatusSeeOther)
+			return
+		}
+		renderConsentPage(w, client, ParseScope(scope), r.URL.Query())
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	if session, ok := SessionFromContext(r.Context()); ok {
+		session.Set(sessionKeyPendingAuth, nil)
+	}
+	if r.FormValue("approve") != "true" {
+		redirectWithError(w, r, redirectURI, state, "access_denied")
+		return
+	}
+
+	session, ok := SessionFromContext(r.Context())
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+	userID, ok := sessionUserID(session)
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		http.Error(w, "failed to generate authorization code", http.StatusInternalServerError)
+		return
+	}
+
+	err = s.tokens.SaveAuthCode(code, authCode{
+		ClientID:            clientID,
+		UserID:              userID,
+		Scope:               scope,
+		RedirectURI:         redirectURI,
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: challengeMethod,
+		ExpiresAt:           time.Now().Add(2 * time.Minute),
+	})
+	if err != nil {
+		http.Error(w, "failed to persist authorization code", http.StatusInternalServerError)
+		return
+	}
+
+	values := redirectURI + "?code=" + code
+	if state != "" {
+		values += "&state=" + state
+	}
+	http.Redirect(w, r, values, http.StatusFound)
+}
+
+// Token implements POST /token for the authorization_code (with PKCE),
+// refresh_token, and client_credentials grants.
+func (s *AuthorizationServer) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, http.StatusBadRequest, "invalid form")
+		return
+	}
+
+	switch r.FormValue("grant_type") {
+	case "authorization_code":
+		s.tokenFromAuthCode(w, r)
+	case "refresh_token":
+		s.tokenFromRefreshToken(w, r)
+	case "client_credentials":
+		s.tokenFromClientCredentials(w, r)
+	default:
+		writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+	}
+}
+
+func (s *AuthorizationServer) tokenFromAuthCode(w http.ResponseWriter, r *http.Request) {
+	code := r.FormValue("code")
+	data, err := s.tokens.ConsumeAuthCode(code)
+	if err != nil {
+		writeTokenError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if data.ClientID != r.FormValue("client_id") || data.RedirectURI != r.FormValue("redirect_uri") {
+		writeTokenError(w, http.StatusBadRequest, "client_id/redirect_uri mismatch")
+		return
+	}
+
+	if data.CodeChallenge != "" {
+		if !verifyPKCE(data.CodeChallenge, data.CodeChallengeMethod, r.FormValue("code_verifier")) {
+			writeTokenError(w, http.StatusBadRequest, "invalid code_verifier")
+			return
+		}
+	}
+
+	user, err := s.users.GetUserByID(data.UserID)
+	if err != nil {
+		writeTokenError(w, http.StatusBadRequest, "unknown user")
+		return
+	}
+
+	s.issueTokenResponse(w, *user, data.ClientID, data.Scope)
+}
+
+func (s *AuthorizationServer) tokenFromRefreshToken(w http.ResponseWriter, r *http.Request) {
+	data, err := s.tokens.ConsumeRefreshToken(r.FormValue("refresh_token"))
+	if err != nil {
+		writeTokenError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := s.users.GetUserByID(data.UserID)
+	if err != nil {
+		writeTokenError(w, http.StatusBadRequest, "unknown user")
+		return
+	}
+
+	s.issueTokenResponse(w, *user, data.ClientID, data.Scope)
+}
+
+func (s *AuthorizationServer) tokenFromClientCredentials(w http.ResponseWriter, r *http.Request) {
+	clientID := r.FormValue("client_id")
+	client, err := s.clients.GetByID(clientID)
+	if err != nil || client.Secret != r.FormValue("client_secret") {
+		writeTokenError(w, http.StatusUnauthorized, "invalid client credentials")
+		return
+	}
+
+	now := time.Now()
+	claims := Claims{
+		Subject:   clientID,
+		Audience:  clientID,
+		Scope:     strings.Join(client.Scopes, " "),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Duration(s.cfg.JWTExpiryHour) * time.Hour).Unix(),
+	}
+
+	token, err := signClaims(claims, s.cfg.JWTSecret)
+	if err != nil {
+		writeTokenError(w, http.StatusInternalServerError, "failed to sign token")
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   s.cfg.JWTExpiryHour * 3600,
+		"scope":        claims.Scope,
+	})
+}
+
+func (s *AuthorizationServer) issueTokenResponse(w http.ResponseWriter, user User, clientID, scope string) {
+	now := time.Now()
+	claims := Claims{
+		Subject:   fmt.Sprintf("%d", user.ID),
+		Audience:  clientID,
+		Scope:     scope,
+		Roles:     []string{user.Role},
+		Email:     user.Email,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Duration(s.cfg.JWTExpiryHour) * time.Hour).Unix(),
+	}
+
+	accessToken, err := signClaims(claims, s.cfg.JWTSecret)
+	if err != nil {
+		writeTokenError(w, http.StatusInternalServerError, "failed to sign token")
+		return
+	}
+
+	refresh, err := randomToken(32)
+	if err != nil {
+		writeTokenError(w, http.StatusInternalServerError, "failed to generate refresh token")
+		return
+	}
+	if err := s.tokens.SaveRefreshToken(refresh, refreshToken{ClientID: clientID, UserID: user.ID, Scope: scope}); err != nil {
+		writeTokenError(w, http.StatusInternalServerError, "failed to persist refresh token")
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refresh,
+		"token_type":    "Bearer",
+		"expires_in":    s.cfg.JWTExpiryHour * 3600,
+		"scope":         scope,
+	})
+}
+
+// OpenIDConfiguration implements GET /.well-known/openid-configuration.
+func (s *AuthorizationServer) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, providerMetadata{
+		Issuer:                s.baseURL,
+		AuthorizationEndpoint: s.baseURL + "/authorize",
+		TokenEndpoint:         s.baseURL + "/token",
+		JWKSURI:               s.baseURL + "/jwks.json",
+	})
+}
+
+// JWKS implements GET /jwks.json. Access tokens are signed with HS256
+// (a shared secret), so there is no public key to publish; this returns
+// an empty key set and exists so JWKS-aware clients don't 404. Moving to
+// RS256 would let this return real keys.
+func (s *AuthorizationServer) JWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{"keys": []interface{}{}})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeTokenError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func redirectWithError(w http.ResponseWriter, r *http.Request, redirectURI, state, code string) {
+	dest := redirectURI + "?error=" + code
+	if state != "" {
+		dest += "&state=" + state
+	}
+	http.Redirect(w, r, dest, http.StatusFound)
+}
+
+// LoginPage implements GET/POST /authorize/login: the page an
+// unauthenticated GET /authorize redirects to. GET renders a bare
+// username/password form; POST authenticates via AuthService, stores
+// the session the same way AuthHandler.Login does, and resumes the
+// pending /authorize request stashed under sessionKeyPendingAuth (or
+// redirects to "/" if there is none, e.g. someone hit this page
+// directly).
+func (s *AuthorizationServer) LoginPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		renderLoginPage(w, "")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	token, err := s.auth.Login(r.FormValue("username"), r.FormValue("password"))
+	if err != nil {
+		renderLoginPage(w, "invalid username or password")
+		return
+	}
+
+	session, ok := SessionFromContext(r.Context())
+	if !ok {
+		http.Error(w, "session store unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := s.auth.ValidateToken(token)
+	if err != nil {
+		http.Error(w, "failed to resolve authenticated user", http.StatusInternalServerError)
+		return
+	}
+	session.Set(sessionKeyUserID, user.ID)
+	session.Set(sessionKeyRoles, user.Role)
+
+	redirectTo := "/"
+	if pending, ok := session.Get(sessionKeyPendingAuth); ok {
+		if query, ok := pending.(string); ok && query != "" {
+			redirectTo = "/authorize?" + query
+		}
+	}
+	session.Set(sessionKeyPendingAuth, nil)
+	http.Redirect(w, r, redirectTo, http.StatusSeeOther)
+}
+
+// renderLoginPage writes the bare login form LoginPage serves on GET,
+// optionally with loginError (e.g. after a failed attempt) escaped into
+// the page.
+func renderLoginPage(w http.ResponseWriter, loginError string) {
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprint(w, "<h1>Log in</h1>")
+	if loginError != "" {
+		fmt.Fprintf(w, "<p>%s</p>", html.EscapeString(loginError))
+	}
+	fmt.Fprint(w, "<form method=\"POST\">")
+	fmt.Fprint(w, "<input type=\"text\" name=\"username\" placeholder=\"username\">")
+	fmt.Fprint(w, "<input type=\"password\" name=\"password\" placeholder=\"password\">")
+	fmt.Fprint(w, "<button type=\"submit\">Log in</button>")
+	fmt.Fprint(w, "</form>")
+}
+
+// renderConsentPage renders a minimal HTML form listing the requested
+// scopes, resubmitting every original query parameter on approval.
+func renderConsentPage(w http.ResponseWriter, client ClientInfo, scope Scope, original map[string][]string) {
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, "<h1>%s is requesting access</h1><ul>", html.EscapeString(client.ID))
+	for _, sc := range scope {
+		fmt.Fprintf(w, "<li>%s</li>", html.EscapeString(sc))
+	}
+	fmt.Fprint(w, "</ul><form method=\"POST\">")
+	for key, values := range original {
+		for _, v := range values {
+			fmt.Fprintf(w, "<input type=\"hidden\" name=\"%s\" value=\"%s\">", html.EscapeString(key), html.EscapeString(v))
+		}
+	}
+	fmt.Fprint(w, "<button type=\"submit\" name=\"approve\" value=\"true\">Allow</button>")
+	fmt.Fprint(w, "<button type=\"submit\" name=\"approve\" value=\"false\">Deny</button>")
+	fmt.Fprint(w, "</form>")
+}
+
+func verifyPKCE(challenge, method, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	switch method {
+	case "S256", "":
+		sum := sha256.Sum256([]byte(verifier))
+		return base64.RawURLEncoding.EncodeToString(sum[:]) == challenge
+	case "plain":
+		return verifier == challenge
+	default:
+		return false
+	}
+}
+
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func stringInSlice(s string, list []string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionUserID extracts the authenticated user ID AuthHandler.Login
+// stored under sessionKeyUserID. Values set in-process come through as
+// int; values round-tripped through a JSON-backed Store (fileStore,
+// redisStore) decode as float64, so both are accepted.
+func sessionUserID(session *Session) (int, bool) {
+	raw, ok := session.Get(sessionKeyUserID)
+	if !ok {
+		return 0, false
+	}
+	switch v := raw.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
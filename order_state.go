@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidTransition is returned when TransitionTo is asked to move an
+// order between two statuses the state machine doesn't allow.
+type ErrInvalidTransition struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *ErrInvalidTransition) Error() string {
+	return fmt.Sprintf("order_state: cannot transition from %q to %q", e.From, e.To)
+}
+
+// orderTransitions maps each status to the set of statuses it may move
+// to next. Cancellation is only reachable pre-shipment; returns only
+// start from delivered.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending:         {OrderConfirmed, OrderCancelled},
+	OrderConfirmed:       {OrderProcessing, OrderCancelled},
+	OrderProcessing:      {OrderShipped, OrderCancelled},
+	OrderShipped:         {OrderDelivered},
+	OrderDelivered:       {OrderReturnRequested},
+	OrderReturnRequested: {OrderReturnApproved, OrderReturnCancelled},
+	OrderReturnApproved:  {OrderReturned},
+}
+
+// OrderStatusHistory records one status transition for audit purposes.
+type OrderStatusHistory struct {
+	BaseModel
+	OrderID uint        `json:"order_id" gorm:"not null"`
+	From    OrderStatus `json:"from"`
+	To      OrderStatus `json:"to"`
+	ActorID uint        `json:"actor_id"`
+	Reason  string      `json:"reason"`
+}
+
+// ReturnModel tracks a return request against an order item, from
+// request through resolution.
+type ReturnModel struct {
+	BaseModel
+	OrderID      uint           `json:"order_id" gorm:"not null"`
+	OrderItemID  uint           `json:"order_item_id" gorm:"not null"`
+	OrderItem    OrderItemModel `json:"order_item,omitempty"`
+	RequestedQty int            `json:"requested_qty" gorm:"not null"`
+	Reason       string         `json:"reason" gorm:"type:text"`
+	Resolution   string         `json:"resolution"` // approved, rejected, pending
+	RefundAmount float64        `json:"refund_amount" gorm:"type:decimal(10,2)"`
+}
+
+// AfterCreate publishes "order.created" through the default event bus.
+func (o *OrderModel) AfterCreate() error {
+	if err := o.BaseModel.AfterCreate(); err != nil {
+		return err
+	}
+	return emitLifecycleEvent(defaultEventPublisher, "created", o)
+}
+
+// OrderStatusWriter is the persistence surface a single transition
+// needs: write the new status and append a history row.
+type OrderStatusWriter interface {
+	UpdateStatus(ctx context.Context, orderID uint, status OrderStatus) error
+	AppendHistory(ctx context.Context, history OrderStatusHistory) error
+}
+
+// OrderStatusStore runs a sequence of OrderStatusWriter calls atomically.
+// TransitionTo uses it so the status update and the history append
+// either both land or neither does.
+type OrderStatusStore interface {
+	WithTransaction(fn func(OrderStatusWriter) error) error
+}
+
+// sqlOrderStatusWriter implements OrderStatusWriter against an
+// in-flight transaction.
+type sqlOrderStatusWriter struct {
+	tx *sql.Tx
+}
+
+func (w *sqlOrderStatusWriter) UpdateStatus(ctx context.Context, orderID uint, status OrderStatus) error {
+	_, err := w.tx.ExecContext(ctx, "UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3", status, time.Now(), orderID)
+	return err
+}
+
+func (w *sqlOrderStatusWriter) AppendHistory(ctx context.Context, history OrderStatusHistory) error {
+	_, err := w.tx.ExecContext(ctx,
+		"INSERT INTO order_status_histories (order_id, from_status, to_status, actor_id, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		history.OrderID, history.From, history.To, history.ActorID, history.Reason, history.CreatedAt)
+	return err
+}
+
+// sqlOrderStatusStore is the *sql.DB-backed OrderStatusStore: each
+// WithTransaction call gets its own transaction, committed only if fn
+// succeeds.
+type sqlOrderStatusStore struct {
+	db *sql.DB
+}
+
+// NewSQLOrderStatusStore returns an OrderStatusStore that runs
+// transitions inside a database/sql transaction.
+func NewSQLOrderStatusStore(db *sql.DB) OrderStatusStore {
+	return &sqlOrderStatusStore{db: db}
+}
+
+func (s *sqlOrderStatusStore) WithTransaction(fn func(OrderStatusWriter) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("order_state: begin transaction: %w", err)
+	}
+
+	if err := fn(&sqlOrderStatusWriter{tx: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("order_state: commit transaction: %w", err)
+	}
+	return nil
+}
+
+// TransitionTo moves order from its current status to next, recording
+// the transition, or returns ErrInvalidTransition if the move isn't
+// allowed by orderTransitions. The status update and history append run
+// inside a single store.WithTransaction call so a failure to append the
+// history can't leave the status changed with no audit trail.
+func (o *OrderModel) TransitionTo(ctx context.Context, store OrderStatusStore, next OrderStatus, actor uint, reason string) error {
+	allowed := orderTransitions[o.Status]
+	ok := false
+	for _, candidate := range allowed {
+		if candidate == next {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return &ErrInvalidTransition{From: o.Status, To: next}
+	}
+
+	from := o.Status
+	history := OrderStatusHistory{
+		OrderID: o.ID,
+		From:    from,
+		To:      next,
+		ActorID: actor,
+		Reason:  reason,
+	}
+	history.CreatedAt = time.Now()
+
+	err := store.WithTransaction(func(writer OrderStatusWriter) error {
+		if err := writer.UpdateStatus(ctx, o.ID, next); err != nil {
+			return fmt.Errorf("order_state: update status: %w", err)
+		}
+		if err := writer.AppendHistory(ctx, history); err != nil {
+			return fmt.Errorf("order_state: append history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	o.Status = next
+	if next == OrderReturned {
+		o.PaymentStatus = PaymentRefunded
+	}
+
+	_ = defaultEventPublisher.Publish("order.status_changed", OrderStatusChangedEvent{
+		OrderID: o.ID,
+		From:    from,
+		To:      next,
+		ActorID: actor,
+	})
+	return nil
+}
+
+// RefundAmountForReturn sums the unit price * requested quantity for a
+// return's order item, used once a return is approved.
+func RefundAmountForReturn(item OrderItemModel, requestedQty int) float64 {
+	if requestedQty > item.Quantity {
+		requestedQty = item.Quantity
+	}
+	return item.UnitPrice * float64(requestedQty)
+}
+
+// ApproveReturn transitions order into OrderReturnApproved and then
+// prices the return: once TransitionTo has recorded the status change,
+// it computes the refund from the returned item's unit price and
+// requested quantity via RefundAmountForReturn and stamps it onto ret.
+// If the transition fails, ret is left unchanged. Callers are expected to persist the
+// updated ReturnModel themselves (e.g. via a Repository), the same way
+// TransitionTo's caller owns persisting the OrderModel.
+func (o *OrderModel) ApproveReturn(ctx context.Context, store OrderStatusStore, ret *ReturnModel, item OrderItemModel, actor uint, reason string) error {
+	if err := o.TransitionTo(ctx, store, OrderReturnApproved, actor, reason); err != nil {
+		return err
+	}
+	ret.Resolution = "approved"
+	ret.RefundAmount = RefundAmountForReturn(item, ret.RequestedQty)
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import "fmt"
+
+// PaymentMethodSpec describes the constraints a payment method enforces
+// on an order: transaction caps, supported currencies, and whether an
+// address is required (e.g. cash on delivery).
+type PaymentMethodSpec struct {
+	Code              string
+	DisplayName       string
+	MinAmount         float64
+	MaxAmount         float64
+	AllowedCurrencies []string
+	RequiresAddress   bool
+	Enabled           bool
+}
+
+// PaymentRegistry holds the configured PaymentMethodSpecs a store
+// accepts, keyed by Code.
+type PaymentRegistry struct {
+	methods map[string]PaymentMethodSpec
+}
+
+// NewPaymentRegistry returns a PaymentRegistry seeded with the given
+// specs.
+func NewPaymentRegistry(specs ...PaymentMethodSpec) *PaymentRegistry {
+	r := &PaymentRegistry{methods: map[string]PaymentMethodSpec{}}
+	for _, s := range specs {
+		r.methods[s.Code] = s
+	}
+	return r
+}
+
+// Register adds or replaces a PaymentMethodSpec.
+func (r *PaymentRegistry) Register(spec PaymentMethodSpec) {
+	r.methods[spec.Code] = spec
+}
+
+// Get returns the spec registered for code, if any.
+func (r *PaymentRegistry) Get(code string) (PaymentMethodSpec, bool) {
+	spec, ok := r.methods[code]
+	return spec, ok
+}
+
+// List returns every registered spec.
+func (r *PaymentRegistry) List() []PaymentMethodSpec {
+	specs := make([]PaymentMethodSpec, 0, len(r.methods))
+	for _, s := range r.methods {
+		specs = append(specs, s)
+	}
+	return specs
+}
+
+// Validate checks order.PaymentMethod and order.Currency against the
+// registered spec's caps and allowed currencies.
+func (r *PaymentRegistry) Validate(order *OrderModel) error {
+	spec, ok := r.methods[order.PaymentMethod]
+	if !ok {
+		return fmt.Errorf("payment: unknown payment method %q", order.PaymentMethod)
+	}
+	if !spec.Enabled {
+		return fmt.Errorf("payment: method %q is disabled", order.PaymentMethod)
+	}
+	if !stringInSlice(order.Currency, spec.AllowedCurrencies) {
+		return fmt.Errorf("payment: currency %q not allowed for method %q", order.Currency, order.PaymentMethod)
+	}
+	if order.TotalAmount < spec.MinAmount {
+		return fmt.Errorf("payment: amount %.2f below minimum %.2f for method %q", order.TotalAmount, spec.MinAmount, order.PaymentMethod)
+	}
+	if spec.MaxAmount > 0 && order.TotalAmount > spec.MaxAmount {
+		return fmt.Errorf("payment: amount %.2f exceeds cap %.2f for method %q", order.TotalAmount, spec.MaxAmount, order.PaymentMethod)
+	}
+	if spec.RequiresAddress && order.ShippingAddressID == 0 {
+		return fmt.Errorf("payment: method %q requires a shipping address", order.PaymentMethod)
+	}
+	return nil
+}
+
+// defaultPaymentRegistry is seeded with the payment methods the store
+// supports out of the box. Caps can be overridden at runtime by passing
+// PaymentMethodConfigModel rows to ApplyConfig; nothing in this package
+// loads those rows at startup.
+var defaultPaymentRegistry = NewPaymentRegistry(
+	PaymentMethodSpec{Code: "cod", DisplayName: "Cash on Delivery", MinAmount: 0, MaxAmount: 5000, AllowedCurrencies: []string{"USD", "INR"}, RequiresAddress: true, Enabled: true},
+	PaymentMethodSpec{Code: "stripe", DisplayName: "Stripe", MinAmount: 0, MaxAmount: 999999, AllowedCurrencies: []string{"USD", "EUR", "GBP"}, RequiresAddress: false, Enabled: true},
+	PaymentMethodSpec{Code: "razorpay", DisplayName: "Razorpay", MinAmount: 0, MaxAmount: 500000, AllowedCurrencies: []string{"INR"}, RequiresAddress: false, Enabled: true},
+)
+
+// PaymentMethodConfigModel persists an admin override of a
+// PaymentMethodSpec's caps, applied over the in-process defaults when
+// passed to ApplyConfig.
+type PaymentMethodConfigModel struct {
+	BaseModel
+	Code              string  `json:"code" gorm:"uniqueIndex;not null"`
+	DisplayName       string  `json:"display_name"`
+	MinAmount         float64 `json:"min_amount" gorm:"type:decimal(10,2);default:0"`
+	MaxAmount         float64 `json:"max_amount" gorm:"type:decimal(10,2);default:0"`
+	AllowedCurrencies string  `json:"allowed_currencies" gorm:"type:varchar(255)"` // comma-separated
+	RequiresAddress   bool    `json:"requires_address"`
+	Enabled           bool    `json:"enabled" gorm:"default:true"`
+}
+
+// ApplyConfig overrides r's spec for cfg.Code with the persisted values.
+func (r *PaymentRegistry) ApplyConfig(cfg PaymentMethodConfigModel) {
+	r.Register(PaymentMethodSpec{
+		Code:              cfg.Code,
+		DisplayName:       cfg.DisplayName,
+		MinAmount:         cfg.MinAmount,
+		MaxAmount:         cfg.MaxAmount,
+		AllowedCurrencies: splitCSV(cfg.AllowedCurrencies),
+		RequiresAddress:   cfg.RequiresAddress,
+		Enabled:           cfg.Enabled,
+	})
+}
+
+func splitCSV(s string) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == ',' {
+			if s[start:i] != "" {
+				out = append(out, s[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if s[start:] != "" {
+		out = append(out, s[start:])
+	}
+	return out
+}
+
+// BeforeCreate validates the order's payment method against
+// defaultPaymentRegistry before the row is written.
+func (o *OrderModel) BeforeCreate() error {
+	if err := o.BaseModel.BeforeCreate(); err != nil {
+		return err
+	}
+	return defaultPaymentRegistry.Validate(o)
+}
+
+// BeforeUpdate re-validates the payment method on every update, since
+// PaymentMethod, Currency, or TotalAmount may change after creation.
+func (o *OrderModel) BeforeUpdate() error {
+	if err := o.BaseModel.BeforeUpdate(); err != nil {
+		return err
+	}
+	return defaultPaymentRegistry.Validate(o)
+}
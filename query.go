@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Apply filters, sorts, and paginates items according to params, using
+// each struct field's `json` tag to resolve Filter.Field/Sort.Field names.
+func Apply[T any](items []T, params QueryParams) ([]T, Pagination, error) {
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		ok, err := matchesFilters(item, params.Filters)
+		if err != nil {
+			return nil, Pagination{}, err
+		}
+		if ok {
+			filtered = append(filtered, item)
+		}
+	}
+
+	if err := applySort(filtered, params.Sort); err != nil {
+		return nil, Pagination{}, err
+	}
+
+	page := params.Pagination
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+	if page.Page <= 0 {
+		page.Page = 1
+	}
+
+	total := len(filtered)
+	page.Total = total
+	page.TotalPages = (total + page.Limit - 1) / page.Limit
+	page.HasPrev = page.Page > 1
+	page.HasNext = page.Page < page.TotalPages
+
+	start := (page.Page - 1) * page.Limit
+	if start > total {
+		start = total
+	}
+	end := start + page.Limit
+	if end > total {
+		end = total
+	}
+
+	return filtered[start:end], page, nil
+}
+
+func matchesFilters(item interface{}, filters []Filter) (bool, error) {
+	for _, f := range filters {
+		value, ok := fieldByJSONName(item, f.Field)
+		if !ok {
+			return false, fmt.Errorf("query: unknown field %q", f.Field)
+		}
+
+		ok, err := matchOperator(value, f.Operator, f.Value)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchOperator(value interface{}, op string, target interface{}) (bool, error) {
+	switch op {
+	case "eq":
+		return compareEqual(value, target), nil
+	case "ne":
+		return !compareEqual(value, target), nil
+	case "gt", "gte", "lt", "lte":
+		cmp, ok := compareNumeric(value, target)
+		if !ok {
+			return false, fmt.Errorf("query: %q operator requires numeric operands", op)
+		}
+		switch op {
+		case "gt":
+			return cmp > 0, nil
+		case "gte":
+			return cmp >= 0, nil
+		case "lt":
+			return cmp < 0, nil
+		default:
+			return cmp <= 0, nil
+		}
+	case "in":
+		values, ok := target.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("query: %q operator requires a list value", op)
+		}
+		for _, v := range values {
+			if compareEqual(value, v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "like":
+		pattern, ok := target.(string)
+		if !ok {
+			return false, fmt.Errorf("query: %q operator requires a string value", op)
+		}
+		return matchLike(fmt.Sprintf("%v", value), pattern), nil
+	case "between":
+		bounds, ok := target.([]interface{})
+		if !ok || len(bounds) != 2 {
+			return false, fmt.Errorf("query: %q operator requires a two-element value", op)
+		}
+		lo, ok1 := compareNumeric(value, bounds[0])
+		hi, ok2 := compareNumeric(value, bounds[1])
+		if !ok1 || !ok2 {
+			return false, fmt.Errorf("query: %q operator requires numeric operands", op)
+		}
+		return lo >= 0 && hi <= 0, nil
+	default:
+		return false, fmt.Errorf("query: unsupported operator %q", op)
+	}
+}
+
+func compareEqual(a, b interface{}) bool {
+	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+}
+
+func compareNumeric(a, b interface{}) (int, bool) {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if !aok || !bok {
+		return 0, false
+	}
+	switch {
+	case af < bf:
+		return -1, true
+	case af > bf:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// matchLike implements SQL-style LIKE with '%' as a wildcard (anchored
+// match when no '%' is present).
+func matchLike(value, pattern string) bool {
+	segments := strings.Split(pattern, "%")
+	anchored := !strings.HasPrefix(pattern, "%")
+	anchoredEnd := !strings.HasSuffix(pattern, "%")
+
+	pos := 0
+	for i, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		if i == len(segments)-1 && anchoredEnd {
+			// The last segment must end the value, so match it against
+			// the tail rather than its first occurrence.
+			if i == 0 {
+				return value == seg
+			}
+			return strings.HasSuffix(value[pos:], seg)
+		}
+		idx := strings.Index(value[pos:], seg)
+		if idx == -1 {
+			return false
+		}
+		if i == 0 && anchored && idx != 0 {
+			return false
+		}
+		pos += idx + len(seg)
+		if i == len(segments)-1 && anchoredEnd && pos != len(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func applySort[T any](items []T, sorts []Sort) error {
+	if len(sorts) == 0 {
+		return nil
+	}
+
+	var sortErr error
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, s := range sorts {
+			vi, ok := fieldByJSONName(items[i], s.Field)
+			if !ok {
+				sortErr = fmt.Errorf("query: unknown sort field %q", s.Field)
+				return false
+			}
+			vj, _ := fieldByJSONName(items[j], s.Field)
+
+			cmp, ok := compareNumeric(vi, vj)
+			if !ok {
+				cmp = strings.Compare(fmt.Sprintf("%v", vi), fmt.Sprintf("%v", vj))
+			}
+			if cmp == 0 {
+				continue
+			}
+			if strings.EqualFold(s.Order, "desc") {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	return sortErr
+}
+
+// fieldByJSONName looks up item's field whose `json` tag matches name.
+func fieldByJSONName(item interface{}, name string) (interface{}, bool) {
+	v := reflect.ValueOf(item)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		tagName := strings.Split(tag, ",")[0]
+		if tagName == name || (tagName == "" && field.Name == name) {
+			return v.Field(i).Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// ---- Cache layer ------------------------------------------------------
+
+// CacheClient is the minimal Redis surface the query cache needs, kept
+// as an interface so callers can plug in a real Redis client.
+type CacheClient interface {
+	Get(key string) (string, error)
+	Set(key string, value string, ttlSeconds int) error
+	DeletePattern(pattern string) error
+}
+
+// QueryCache memoizes Apply results behind a CacheClient, keyed by an
+// MD5 hash of the canonicalised QueryParams.
+type QueryCache struct {
+	client CacheClient
+	cfg    CacheConfig
+}
+
+// NewQueryCache wraps client using cfg.TTL as the default expiry.
+func NewQueryCache(client CacheClient, cfg CacheConfig) *QueryCache {
+	return &QueryCache{client: client, cfg: cfg}
+}
+
+// Key returns the cache key for a (model, params) query.
+func (c *QueryCache) Key(model string, params QueryParams) (string, error) {
+	canonical, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return "query:" + model + ":" + (HashUtils{}).MD5Hash(string(canonical)), nil
+}
+
+// Get returns the cached JSON payload for (model, params), if present.
+func (c *QueryCache) Get(model string, params QueryParams) (string, bool, error) {
+	key, err := c.Key(model, params)
+	if err != nil {
+		return "", false, err
+	}
+	val, err := c.client.Get(key)
+	if err != nil {
+		return "", false, nil
+	}
+	return val, val != "", nil
+}
+
+// Set stores payload for (model, params) under the configured TTL.
+func (c *QueryCache) Set(model string, params QueryParams, payload string) error {
+	key, err := c.Key(model, params)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(key, payload, c.cfg.TTL)
+}
+
+// Invalidate evicts every cached query for model. The repositories in
+// repository.go do not call it from Create/Update/Delete; cached pages
+// stay until Invalidate is called or their TTL expires.
+func (c *QueryCache) Invalidate(model string) error {
+	return c.client.DeletePattern("query:" + model + ":*")
+}
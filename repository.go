@@ -0,0 +1,543 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RepositoryConfig configures which backend NewRepository constructs.
+type RepositoryConfig struct {
+	Backend  string         `json:"backend"` // "sql" or "nats"
+	Database DatabaseConfig `json:"database"`
+	NATSURL  string         `json:"nats_url"`
+}
+
+// NewRepository returns a Repository for the given domain model backed by
+// whichever store RepositoryConfig.Backend selects.
+func NewRepository(cfg RepositoryConfig, model string) (Repository, error) {
+	switch cfg.Backend {
+	case "sql":
+		db, err := openDatabase(cfg.Database)
+		if err != nil {
+			return nil, fmt.Errorf("repository: open database: %w", err)
+		}
+		return NewSQLRepository(db, model), nil
+	case "nats":
+		conn, err := dialNATS(cfg.NATSURL)
+		if err != nil {
+			return nil, fmt.Errorf("repository: dial nats: %w", err)
+		}
+		return NewNATSRepository(conn, model), nil
+	default:
+		return nil, fmt.Errorf("repository: unknown backend %q", cfg.Backend)
+	}
+}
+
+// openDatabase opens a *sql.DB for cfg. The driver is registered by the
+// caller's build (e.g. via a blank import of a database/sql driver).
+func openDatabase(cfg DatabaseConfig) (*sql.DB, error) {
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
+	return sql.Open("postgres", dsn)
+}
+
+// FindAllTyped runs params through repo and decodes the results into T,
+// giving callers a typed slice alongside the Pagination metadata.
+func FindAllTyped[T any](repo Repository, params QueryParams) ([]T, Pagination, error) {
+	raw, page, err := repo.FindAll(params)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+
+	items := make([]T, 0, len(raw))
+	for _, r := range raw {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return nil, Pagination{}, fmt.Errorf("repository: marshal row: %w", err)
+		}
+		var item T
+		if err := json.Unmarshal(b, &item); err != nil {
+			return nil, Pagination{}, fmt.Errorf("repository: decode row: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, page, nil
+}
+
+// ---- SQL backend ----------------------------------------------------------
+
+// sqlRepository implements Repository on top of database/sql, translating
+// QueryParams into parameterised WHERE/ORDER BY/LIMIT clauses.
+type sqlRepository struct {
+	db      *sql.DB
+	table   string
+	columns map[string]bool // allow-list of filter/sort field names; nil means "model unknown, fall back to identifier check"
+}
+
+// NewSQLRepository builds a Repository backed by db for the named model's
+// table (e.g. "users", "orders").
+func NewSQLRepository(db *sql.DB, model string) Repository {
+	return &sqlRepository{db: db, table: tableNameFor(model), columns: columnsFor(model)}
+}
+
+// modelRegistry maps a Repository model name to the zero value of its
+// plain struct, used to allow-list the field names FindAll accepts in
+// Filter/Sort before they're interpolated into SQL.
+var modelRegistry = map[string]interface{}{
+	"user":         User{},
+	"product":      Product{},
+	"order":        Order{},
+	"orderitem":    OrderItem{},
+	"address":      Address{},
+	"category":     Category{},
+	"review":       Review{},
+	"cart":         Cart{},
+	"cartitem":     CartItem{},
+	"payment":      Payment{},
+	"notification": Notification{},
+}
+
+// columnsFor returns the set of JSON field names for model's registered
+// struct, or nil if model isn't registered.
+func columnsFor(model string) map[string]bool {
+	zero, ok := modelRegistry[strings.ToLower(model)]
+	if !ok {
+		return nil
+	}
+
+	t := reflect.TypeOf(zero)
+	cols := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "" {
+			tag = field.Name
+		}
+		cols[tag] = true
+	}
+	return cols
+}
+
+// identifierPattern is the fallback safety net for models not present in
+// modelRegistry: it rejects anything that isn't a bare SQL identifier,
+// so a Filter/Sort field can never smuggle SQL syntax even if the
+// allow-list above doesn't know about the model.
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validateColumn checks field against r's column allow-list (if the
+// model is registered) and, always, against identifierPattern.
+func (r *sqlRepository) validateColumn(field string) error {
+	if !identifierPattern.MatchString(field) {
+		return fmt.Errorf("repository: invalid field name %q", field)
+	}
+	if r.columns != nil && !r.columns[field] {
+		return fmt.Errorf("repository: unknown field %q for %s", field, r.table)
+	}
+	return nil
+}
+
+func tableNameFor(model string) string {
+	return strings.ToLower(model) + "s"
+}
+
+// Lifecycle hooks mirror GORM's: a model opts in by implementing the
+// matching optional interface (models.go's BaseModel supplies defaults
+// for BeforeCreate/BeforeUpdate, which stamp timestamps, and no-op
+// AfterCreate/AfterUpdate, and
+// individual models add BeforeSave for JSONB validation or override
+// the Before/After hooks for domain-specific checks and events). Create
+// and Update run them around the INSERT/UPDATE so those hooks actually
+// fire on the write path instead of sitting unused.
+type beforeCreateHook interface{ BeforeCreate() error }
+type beforeUpdateHook interface{ BeforeUpdate() error }
+type beforeSaveHook interface{ BeforeSave() error }
+type afterCreateHook interface{ AfterCreate() error }
+type afterUpdateHook interface{ AfterUpdate() error }
+
+func runBeforeWriteHooks(model interface{}) error {
+	if hook, ok := model.(beforeCreateHook); ok {
+		if err := hook.BeforeCreate(); err != nil {
+			return fmt.Errorf("repository: BeforeCreate: %w", err)
+		}
+	}
+	if hook, ok := model.(beforeSaveHook); ok {
+		if err := hook.BeforeSave(); err != nil {
+			return fmt.Errorf("repository: BeforeSave: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *sqlRepository) Create(model interface{}) error {
+	if err := runBeforeWriteHooks(model); err != nil {
+		return err
+	}
+
+	cols, vals, err := columnsAndValues(model)
+	if err != nil {
+		return err
+	}
+
+	placeholders := make([]string, len(cols))
+	for i := range cols {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		r.table, strings.Join(cols, ", "), strings.Join(placeholders, ", "))
+	if _, err := r.db.Exec(query, vals...); err != nil {
+		return err
+	}
+
+	if hook, ok := model.(afterCreateHook); ok {
+		if err := hook.AfterCreate(); err != nil {
+			return fmt.Errorf("repository: AfterCreate: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *sqlRepository) Update(id int, model interface{}) error {
+	if hook, ok := model.(beforeUpdateHook); ok {
+		if err := hook.BeforeUpdate(); err != nil {
+			return fmt.Errorf("repository: BeforeUpdate: %w", err)
+		}
+	}
+	if hook, ok := model.(beforeSaveHook); ok {
+		if err := hook.BeforeSave(); err != nil {
+			return fmt.Errorf("repository: BeforeSave: %w", err)
+		}
+	}
+
+	cols, vals, err := columnsAndValues(model)
+	if err != nil {
+		return err
+	}
+
+	sets := make([]string, len(cols))
+	for i, c := range cols {
+		sets[i] = fmt.Sprintf("%s = $%d", c, i+1)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", r.table, strings.Join(sets, ", "), len(cols)+1)
+	if _, err := r.db.Exec(query, append(vals, id)...); err != nil {
+		return err
+	}
+
+	if hook, ok := model.(afterUpdateHook); ok {
+		if err := hook.AfterUpdate(); err != nil {
+			return fmt.Errorf("repository: AfterUpdate: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *sqlRepository) Delete(id int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", r.table)
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *sqlRepository) FindByID(id int) (interface{}, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", r.table)
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results, err := scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("repository: %s with id %d not found", r.table, id)
+	}
+	return results[0], nil
+}
+
+func (r *sqlRepository) FindAll(params QueryParams) ([]interface{}, Pagination, error) {
+	for _, f := range params.Filters {
+		if err := r.validateColumn(f.Field); err != nil {
+			return nil, Pagination{}, err
+		}
+	}
+	for _, s := range params.Sort {
+		if err := r.validateColumn(s.Field); err != nil {
+			return nil, Pagination{}, err
+		}
+	}
+
+	where, args, err := buildWhere(params.Filters)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+
+	total, err := r.count(where, args)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s", r.table)
+	if where != "" {
+		query += " WHERE " + where
+	}
+	if order := buildOrderBy(params.Sort); order != "" {
+		query += " ORDER BY " + order
+	}
+
+	page := params.Pagination
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+	if page.Page <= 0 {
+		page.Page = 1
+	}
+	offset := (page.Page - 1) * page.Limit
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, page.Limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+	defer rows.Close()
+
+	results, err := scanRows(rows)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+
+	page.Total = total
+	page.TotalPages = (total + page.Limit - 1) / page.Limit
+	page.HasNext = page.Page < page.TotalPages
+	page.HasPrev = page.Page > 1
+	return results, page, nil
+}
+
+func (r *sqlRepository) count(where string, args []interface{}) (int, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", r.table)
+	if where != "" {
+		query += " WHERE " + where
+	}
+	var total int
+	err := r.db.QueryRow(query, args...).Scan(&total)
+	return total, err
+}
+
+// filterOperators maps Filter.Operator to SQL comparison operators.
+var filterOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "!=",
+	"gt":   ">",
+	"lt":   "<",
+	"gte":  ">=",
+	"lte":  "<=",
+	"like": "LIKE",
+	"in":   "IN",
+}
+
+func buildWhere(filters []Filter) (string, []interface{}, error) {
+	if len(filters) == 0 {
+		return "", nil, nil
+	}
+
+	var clauses []string
+	var args []interface{}
+	for _, f := range filters {
+		op, ok := filterOperators[f.Operator]
+		if !ok {
+			return "", nil, fmt.Errorf("repository: unsupported filter operator %q", f.Operator)
+		}
+
+		if f.Operator == "in" {
+			values, ok := f.Value.([]interface{})
+			if !ok {
+				return "", nil, fmt.Errorf("repository: %q filter requires a list value", f.Field)
+			}
+			placeholders := make([]string, len(values))
+			for i, v := range values {
+				args = append(args, v)
+				placeholders[i] = fmt.Sprintf("$%d", len(args))
+			}
+			clauses = append(clauses, fmt.Sprintf("%s IN (%s)", f.Field, strings.Join(placeholders, ", ")))
+			continue
+		}
+
+		args = append(args, f.Value)
+		clauses = append(clauses, fmt.Sprintf("%s %s $%d", f.Field, op, len(args)))
+	}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+func buildOrderBy(sorts []Sort) string {
+	if len(sorts) == 0 {
+		return ""
+	}
+	parts := make([]string, len(sorts))
+	for i, s := range sorts {
+		order := "ASC"
+		if strings.EqualFold(s.Order, "desc") {
+			order = "DESC"
+		}
+		parts[i] = fmt.Sprintf("%s %s", s.Field, order)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// columnsAndValues flattens a model's JSON representation into parallel
+// column/value slices for use in an INSERT or UPDATE statement.
+func columnsAndValues(model interface{}) ([]string, []interface{}, error) {
+	b, err := json.Marshal(model)
+	if err != nil {
+		return nil, nil, err
+	}
+	var asMap map[string]interface{}
+	if err := json.Unmarshal(b, &asMap); err != nil {
+		return nil, nil, err
+	}
+
+	cols := make([]string, 0, len(asMap))
+	vals := make([]interface{}, 0, len(asMap))
+	for k, v := range asMap {
+		if k == "id" {
+			continue
+		}
+		cols = append(cols, k)
+		vals = append(vals, v)
+	}
+	return cols, vals, nil
+}
+
+func scanRows(rows *sql.Rows) ([]interface{}, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []interface{}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			row[c] = vals[i]
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// ---- NATS-RPC backend ------------------------------------------------------
+
+// NATSConn is the minimal subset of a nats.Conn the repository needs,
+// kept as an interface so callers can plug in the real client.
+type NATSConn interface {
+	Request(subject string, data []byte, timeout time.Duration) ([]byte, error)
+}
+
+// dialNATS is a placeholder dialer; real deployments supply their own
+// NATSConn built from a nats.Conn instead of calling this.
+func dialNATS(url string) (NATSConn, error) {
+	return nil, fmt.Errorf("repository: no NATSConn configured for %q; construct one with NewNATSRepository", url)
+}
+
+// natsEnvelope is the request/reply payload shape shared by every subject.
+type natsEnvelope struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// natsRepository implements Repository over NATS request/reply, one
+// subject namespace per model (e.g. "user.findByID", "order.findAll").
+type natsRepository struct {
+	conn    NATSConn
+	model   string
+	timeout time.Duration
+}
+
+// NewNATSRepository builds a Repository that proxies every call through
+// conn under the <model>.<verb> subject namespace.
+func NewNATSRepository(conn NATSConn, model string) Repository {
+	return &natsRepository{conn: conn, model: strings.ToLower(model), timeout: 5 * time.Second}
+}
+
+func (r *natsRepository) subject(verb string) string {
+	return r.model + "." + verb
+}
+
+func (r *natsRepository) call(verb string, payload interface{}) (json.RawMessage, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.conn.Request(r.subject(verb), body, r.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("repository: nats request %s: %w", r.subject(verb), err)
+	}
+
+	var env natsEnvelope
+	if err := json.Unmarshal(resp, &env); err != nil {
+		return nil, fmt.Errorf("repository: decode envelope from %s: %w", r.subject(verb), err)
+	}
+	if env.Error != "" {
+		return nil, fmt.Errorf("repository: %s: %s", r.subject(verb), env.Error)
+	}
+	return env.Data, nil
+}
+
+func (r *natsRepository) Create(model interface{}) error {
+	_, err := r.call("create", model)
+	return err
+}
+
+func (r *natsRepository) Update(id int, model interface{}) error {
+	_, err := r.call("update", map[string]interface{}{"id": id, "model": model})
+	return err
+}
+
+func (r *natsRepository) Delete(id int) error {
+	_, err := r.call("delete", map[string]interface{}{"id": id})
+	return err
+}
+
+func (r *natsRepository) FindByID(id int) (interface{}, error) {
+	data, err := r.call("findByID", map[string]interface{}{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *natsRepository) FindAll(params QueryParams) ([]interface{}, Pagination, error) {
+	data, err := r.call("findAll", params)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+
+	var result struct {
+		Items      []interface{} `json:"items"`
+		Pagination Pagination    `json:"pagination"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, Pagination{}, err
+	}
+	return result.Items, result.Pagination, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Router is a small path-parameter-aware request multiplexer, standing
+// in for chi/gorilla-style routers without taking on the dependency. It
+// matches segment-by-segment so "/users/{id}/orders" can coexist with
+// "/users/{id}".
+type Router struct {
+	prefix      string
+	routes      map[string][]route
+	middlewares []func(http.Handler) http.Handler
+}
+
+type route struct {
+	segments []string
+	handler  http.Handler // already wrapped with the registering Router's middleware chain
+}
+
+// NewRouter returns a Router whose routes are all served under prefix
+// (e.g. "/api/v1").
+func NewRouter(prefix string) *Router {
+	return &Router{prefix: strings.TrimRight(prefix, "/"), routes: map[string][]route{}}
+}
+
+// Use appends middleware that wraps every route registered on the
+// Router after this call (routes registered earlier keep the chain they
+// were registered with), applied in the order added (first added runs
+// outermost).
+func (rt *Router) Use(mw ...func(http.Handler) http.Handler) {
+	rt.middlewares = append(rt.middlewares, mw...)
+}
+
+// Handle registers handler for method and pattern (e.g. GET,
+// "/users/{id}/orders"), wrapping it with rt's middleware chain right
+// now. Capturing the chain at registration time (rather than rt's
+// middlewares field at serve time) means a route added through a
+// Group keeps that group's middleware even though groups share the
+// parent's routes map and only the top-level Router's ServeHTTP ever
+// actually runs.
+func (rt *Router) Handle(method, pattern string, handler http.HandlerFunc) {
+	rt.routes[method] = append(rt.routes[method], route{
+		segments: strings.Split(strings.Trim(pattern, "/"), "/"),
+		handler:  chain(handler, rt.middlewares...),
+	})
+}
+
+func (rt *Router) Get(pattern string, handler http.HandlerFunc)    { rt.Handle(http.MethodGet, pattern, handler) }
+func (rt *Router) Post(pattern string, handler http.HandlerFunc)   { rt.Handle(http.MethodPost, pattern, handler) }
+func (rt *Router) Put(pattern string, handler http.HandlerFunc)    { rt.Handle(http.MethodPut, pattern, handler) }
+func (rt *Router) Delete(pattern string, handler http.HandlerFunc) { rt.Handle(http.MethodDelete, pattern, handler) }
+
+// ServeHTTP resolves r against the registered routes and dispatches to
+// the matching handler. Each route's handler was already wrapped with
+// its registering Router's middleware chain in Handle, so no further
+// wrapping happens here.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, rt.prefix)
+	if path == r.URL.Path && rt.prefix != "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, rte := range rt.routes[r.Method] {
+		if params, ok := matchRoute(rte.segments, segments); ok {
+			rte.handler.ServeHTTP(w, r.WithContext(withURLParams(r.Context(), params)))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func matchRoute(pattern, path []string) (map[string]string, bool) {
+	if len(pattern) != len(path) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, seg := range pattern {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			params[strings.Trim(seg, "{}")] = path[i]
+			continue
+		}
+		if seg != path[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// chain wraps handler with mws in reverse order so the first middleware
+// in the slice is the outermost one executed.
+func chain(handler http.Handler, mws ...func(http.Handler) http.Handler) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
+
+type urlParamsKey struct{}
+
+func withURLParams(ctx context.Context, params map[string]string) context.Context {
+	return context.WithValue(ctx, urlParamsKey{}, params)
+}
+
+// URLParam returns the named path parameter extracted for r, or "" if
+// the current route has no such parameter.
+func URLParam(r *http.Request, name string) string {
+	params, _ := r.Context().Value(urlParamsKey{}).(map[string]string)
+	return params[name]
+}
+
+// Group creates a sub-router sharing Router's prefix/routes but with its
+// own middleware chain, so a set of routes (e.g. admin-only) can layer
+// extra middleware without affecting the rest. Routes registered on the
+// group land in the same routes map the parent serves from, but each
+// gets the group's middleware chain baked in at registration time (see
+// Handle), so the group's middleware still runs even though only the
+// top-level Router's ServeHTTP is ever actually invoked.
+func (rt *Router) Group(mw ...func(http.Handler) http.Handler) *Router {
+	group := &Router{prefix: rt.prefix, routes: rt.routes}
+	group.middlewares = append(append([]func(http.Handler) http.Handler{}, rt.middlewares...), mw...)
+	return group
+}
+
+// RegisterRoutes wires the REST handlers onto router under /api/v1,
+// replacing the ad-hoc r.URL.Path slicing the handlers used before.
+func RegisterRoutes(router *Router, users *UserHandler, products *ProductHandler, orders *OrderHandler, auth *AuthHandler, authCfg AuthConfig) {
+	router.Use(adaptMiddleware(LoggingMiddleware), adaptMiddleware(CORSMiddleware))
+
+	router.Get("/users", users.GetUsers)
+	router.Get("/users/{id}", users.GetUser)
+	router.Post("/users", users.CreateUser)
+	router.Put("/users/{id}", users.UpdateUser)
+	router.Delete("/users/{id}", users.DeleteUser)
+
+	router.Get("/products", products.GetProducts)
+	router.Get("/products/{id}", products.GetProduct)
+
+	protected := router.Group(adaptMiddleware(AuthMiddleware(authCfg)))
+	protected.Post("/orders", orders.CreateOrder)
+	protected.Get("/orders/{id}", orders.GetOrder)
+
+	router.Post("/login", auth.Login)
+	router.Post("/register", auth.Register)
+}
+
+// adaptMiddleware lifts the existing http.HandlerFunc-based middleware
+// into the func(http.Handler) http.Handler shape Router.Use expects.
+func adaptMiddleware(mw func(http.HandlerFunc) http.HandlerFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return mw(next.ServeHTTP)
+	}
+}
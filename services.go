@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// decodeRow converts a Repository row (typically a map[string]interface{}
+// from scanRows) into a concrete model, the same way FindAllTyped does.
+func decodeRow(raw interface{}, out interface{}) error {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// ---- UserService ------------------------------------------------------
+
+// repoUserService implements UserService over a generic Repository.
+type repoUserService struct {
+	repo Repository
+}
+
+// NewRepoUserService returns a UserService backed by repo.
+func NewRepoUserService(repo Repository) UserService {
+	return &repoUserService{repo: repo}
+}
+
+func (s *repoUserService) GetAllUsers() ([]User, error) {
+	users, _, err := FindAllTyped[User](s.repo, QueryParams{})
+	return users, err
+}
+
+func (s *repoUserService) GetUserByID(id int) (*User, error) {
+	raw, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	var user User
+	if err := decodeRow(raw, &user); err != nil {
+		return nil, fmt.Errorf("services: decode user: %w", err)
+	}
+	return &user, nil
+}
+
+func (s *repoUserService) CreateUser(user *User) error {
+	return s.repo.Create(user)
+}
+
+func (s *repoUserService) UpdateUser(user *User) error {
+	return s.repo.Update(user.ID, user)
+}
+
+func (s *repoUserService) DeleteUser(id int) error {
+	return s.repo.Delete(id)
+}
+
+// ---- ProductService -----------------------------------------------------
+
+// repoProductService implements ProductService over a generic Repository.
+type repoProductService struct {
+	repo Repository
+}
+
+// NewRepoProductService returns a ProductService backed by repo.
+func NewRepoProductService(repo Repository) ProductService {
+	return &repoProductService{repo: repo}
+}
+
+func (s *repoProductService) GetAllProducts() ([]Product, error) {
+	products, _, err := FindAllTyped[Product](s.repo, QueryParams{})
+	return products, err
+}
+
+func (s *repoProductService) GetProductByID(id int) (*Product, error) {
+	raw, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	var product Product
+	if err := decodeRow(raw, &product); err != nil {
+		return nil, fmt.Errorf("services: decode product: %w", err)
+	}
+	return &product, nil
+}
+
+func (s *repoProductService) CreateProduct(product *Product) error {
+	return s.repo.Create(product)
+}
+
+func (s *repoProductService) UpdateProduct(product *Product) error {
+	return s.repo.Update(product.ID, product)
+}
+
+func (s *repoProductService) DeleteProduct(id int) error {
+	return s.repo.Delete(id)
+}
+
+// ---- OrderService ---------------------------------------------------------
+
+// repoOrderService implements OrderService over a generic Repository.
+type repoOrderService struct {
+	repo Repository
+}
+
+// NewRepoOrderService returns an OrderService backed by repo.
+func NewRepoOrderService(repo Repository) OrderService {
+	return &repoOrderService{repo: repo}
+}
+
+func (s *repoOrderService) GetAllOrders() ([]Order, error) {
+	orders, _, err := FindAllTyped[Order](s.repo, QueryParams{})
+	return orders, err
+}
+
+func (s *repoOrderService) GetOrderByID(id int) (*Order, error) {
+	raw, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	var order Order
+	if err := decodeRow(raw, &order); err != nil {
+		return nil, fmt.Errorf("services: decode order: %w", err)
+	}
+	return &order, nil
+}
+
+func (s *repoOrderService) CreateOrder(order *Order) error {
+	return s.repo.Create(order)
+}
+
+func (s *repoOrderService) UpdateOrder(order *Order) error {
+	return s.repo.Update(order.ID, order)
+}
+
+func (s *repoOrderService) DeleteOrder(id int) error {
+	return s.repo.Delete(id)
+}
+
+// ---- AuthService ------------------------------------------------------
+
+// repoAuthService implements AuthService on top of a UserService for
+// lookups, HashUtils for password verification, and IssueJWT/ParseJWT
+// for tokens.
+type repoAuthService struct {
+	users UserService
+	cfg   AuthConfig
+	hash  HashUtils
+}
+
+// NewRepoAuthService returns an AuthService backed by users.
+func NewRepoAuthService(users UserService, cfg AuthConfig) AuthService {
+	return &repoAuthService{users: users, cfg: cfg}
+}
+
+func (s *repoAuthService) Login(username, password string) (string, error) {
+	users, err := s.users.GetAllUsers()
+	if err != nil {
+		return "", err
+	}
+	for _, u := range users {
+		if u.Username != username {
+			continue
+		}
+		if !s.hash.VerifyPassword(password, u.Password) {
+			return "", errors.New("services: invalid credentials")
+		}
+		return IssueJWT(u, s.cfg)
+	}
+	return "", errors.New("services: invalid credentials")
+}
+
+func (s *repoAuthService) Register(user *User) error {
+	hashed, err := s.hash.HashPassword(user.Password, s.cfg.BcryptCost)
+	if err != nil {
+		return fmt.Errorf("services: hash password: %w", err)
+	}
+	user.Password = hashed
+	return s.users.CreateUser(user)
+}
+
+func (s *repoAuthService) ValidateToken(token string) (*User, error) {
+	claims, err := ParseJWT(token, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	var id int
+	if _, err := fmt.Sscanf(claims.Subject, "%d", &id); err != nil {
+		return nil, fmt.Errorf("services: invalid token subject: %w", err)
+	}
+	return s.users.GetUserByID(id)
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Session keys used by AuthHandler.Login and the OAuth /authorize flow.
+const (
+	sessionKeyUserID = "user_id"
+	sessionKeyRoles  = "roles"
+	sessionKeyPendingAuth = "pending_authorize"
+)
+
+// Session holds the key/value pairs persisted for one cookie-identified
+// visitor.
+type Session struct {
+	ID       string
+	Values   map[string]interface{}
+	store    Store
+	name     string
+	isNew    bool
+}
+
+// Get returns a value previously set on the session.
+func (s *Session) Get(key string) (interface{}, bool) {
+	v, ok := s.Values[key]
+	return v, ok
+}
+
+// Set stores a value on the session. Save must be called to persist it.
+func (s *Session) Set(key string, value interface{}) {
+	s.Values[key] = value
+}
+
+// Save persists the session back through its Store.
+func (s *Session) Save() error {
+	return s.store.Save(s.ID, s.Values)
+}
+
+// Store is the pluggable session backend, modelled on gorilla/sessions.
+type Store interface {
+	Load(id string) (map[string]interface{}, error)
+	Save(id string, values map[string]interface{}) error
+	Delete(id string) error
+}
+
+// ---- In-memory store --------------------------------------------------
+
+type memoryStore struct {
+	mu   sync.RWMutex
+	data map[string]map[string]interface{}
+}
+
+// NewMemoryStore returns a Store backed by a process-local map. Sessions
+// do not survive a restart.
+func NewMemoryStore() Store {
+	return &memoryStore{data: map[string]map[string]interface{}{}}
+}
+
+func (s *memoryStore) Load(id string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values, ok := s.data[id]
+	if !ok {
+		return map[string]interface{}{}, nil
+	}
+	return values, nil
+}
+
+func (s *memoryStore) Save(id string, values map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[id] = values
+	return nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, id)
+	return nil
+}
+
+// ---- Filesystem store ---------------------------------------------------
+
+type fileStore struct {
+	dir string
+}
+
+// NewFileStore returns a Store that persists each session as a JSON file
+// under dir.
+func NewFileStore(dir string) Store {
+	return &fileStore{dir: dir}
+}
+
+func (s *fileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *fileStore) Load(id string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (s *fileStore) Save(id string, values map[string]interface{}) error {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(id), b, 0600)
+}
+
+func (s *fileStore) Delete(id string) error {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ---- Redis store --------------------------------------------------------
+
+// redisStore persists sessions through the same CacheClient abstraction
+// the query cache uses, so callers plug in one Redis client for both.
+type redisStore struct {
+	client CacheClient
+	ttl    int
+}
+
+// NewRedisStore returns a Store backed by client, expiring entries after
+// ttlSeconds.
+func NewRedisStore(client CacheClient, ttlSeconds int) Store {
+	return &redisStore{client: client, ttl: ttlSeconds}
+}
+
+func (s *redisStore) Load(id string) (map[string]interface{}, error) {
+	raw, err := s.client.Get("session:" + id)
+	if err != nil || raw == "" {
+		return map[string]interface{}{}, nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (s *redisStore) Save(id string, values map[string]interface{}) error {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return s.client.Set("session:"+id, string(b), s.ttl)
+}
+
+func (s *redisStore) Delete(id string) error {
+	return s.client.DeletePattern("session:" + id)
+}
+
+// ---- Cookie signing & middleware -----------------------------------------
+
+// CookieOptions controls how the session cookie is written.
+type CookieOptions struct {
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// keyring signs and verifies session IDs with HMAC-SHA256, supporting
+// zero-downtime rotation: new signatures use keys[0]; verification tries
+// every key in keys.
+type keyring struct {
+	mu   sync.RWMutex
+	keys [][]byte
+}
+
+// RotateKeys replaces the signing keyring. Put the new key first so it
+// signs new cookies; keep the old key(s) so already-issued cookies still
+// verify until they expire.
+func (k *keyring) RotateKeys(keys [][]byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+}
+
+func (k *keyring) sign(value string) string {
+	k.mu.RLock()
+	key := k.keys[0]
+	k.mu.RUnlock()
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return value + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (k *keyring) verify(signed string) (string, bool) {
+	idx := len(signed)
+	for i := len(signed) - 1; i >= 0; i-- {
+		if signed[i] == '.' {
+			idx = i
+			break
+		}
+	}
+	if idx == len(signed) {
+		return "", false
+	}
+	value, sig := signed[:idx], signed[idx+1:]
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range k.keys {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		expected := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+		if hmac.Equal([]byte(expected), []byte(sig)) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// SessionManager ties a Store to a signing keyring and cookie name.
+type SessionManager struct {
+	store   Store
+	name    string
+	keys    *keyring
+	options CookieOptions
+}
+
+// NewSessionManager builds a SessionManager. secret seeds the initial
+// signing key; call RotateKeys later to roll it.
+func NewSessionManager(store Store, name string, secret []byte, options CookieOptions) *SessionManager {
+	return &SessionManager{store: store, name: name, keys: &keyring{keys: [][]byte{secret}}, options: options}
+}
+
+// RotateKeys rolls the signing keyring without invalidating sessions
+// signed under the previous key(s).
+func (m *SessionManager) RotateKeys(keys [][]byte) {
+	m.keys.RotateKeys(keys)
+}
+
+type sessionContextKey struct{}
+
+// SessionMiddleware attaches a *Session to r.Context(), loaded from the
+// signed cookie named by m's cookie name, creating a new session if
+// absent or invalid.
+func SessionMiddleware(m *SessionManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session := m.load(r)
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, session)))
+
+			if err := session.Save(); err == nil {
+				m.writeCookie(w, session)
+			}
+		})
+	}
+}
+
+// SessionFromContext returns the *Session SessionMiddleware attached to
+// ctx, if any.
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionContextKey{}).(*Session)
+	return s, ok
+}
+
+func (m *SessionManager) load(r *http.Request) *Session {
+	cookie, err := r.Cookie(m.name)
+	if err != nil {
+		return m.newSession()
+	}
+
+	id, ok := m.keys.verify(cookie.Value)
+	if !ok {
+		return m.newSession()
+	}
+
+	values, err := m.store.Load(id)
+	if err != nil {
+		return m.newSession()
+	}
+	return &Session{ID: id, Values: values, store: m.store, name: m.name}
+}
+
+func (m *SessionManager) newSession() *Session {
+	id, _ := randomToken(16)
+	return &Session{ID: id, Values: map[string]interface{}{}, store: m.store, name: m.name, isNew: true}
+}
+
+func (m *SessionManager) writeCookie(w http.ResponseWriter, s *Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.name,
+		Value:    m.keys.sign(s.ID),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   m.options.Secure,
+		SameSite: m.options.SameSite,
+		MaxAge:   int((24 * time.Hour).Seconds()),
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims is the JWT payload issued by IssueJWT and read back by ParseJWT.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Role      string   `json:"role"`
+	Email     string   `json:"email"`
+	Audience  string   `json:"aud,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// IssueJWT signs a JWT for user using cfg.JWTSecret, expiring after
+// cfg.JWTExpiryHour hours.
+func IssueJWT(user User, cfg AuthConfig) (string, error) {
+	if cfg.JWTSecret == "" {
+		return "", errors.New("token: AuthConfig.JWTSecret is empty")
+	}
+
+	now := time.Now()
+	claims := Claims{
+		Subject:   fmt.Sprintf("%d", user.ID),
+		Role:      user.Role,
+		Email:     user.Email,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Duration(cfg.JWTExpiryHour) * time.Hour).Unix(),
+	}
+	return signClaims(claims, cfg.JWTSecret)
+}
+
+// signClaims encodes and HMAC-signs claims, producing a compact JWT.
+func signClaims(claims Claims, secret string) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+
+	signingInput := jwtHeader + "." + encodedPayload
+	signature := signJWT(signingInput, secret)
+
+	return signingInput + "." + signature, nil
+}
+
+// ParseJWT verifies token's signature and expiry against cfg and returns
+// its claims.
+func ParseJWT(token string, cfg AuthConfig) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token: malformed JWT")
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(signJWT(signingInput, cfg.JWTSecret)), []byte(parts[2])) {
+		return nil, errors.New("token: invalid signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("token: decode payload: %w", err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("token: decode claims: %w", err)
+	}
+
+	if time.Now().Unix() > claims.ExpiresAt {
+		return nil, errors.New("token: expired")
+	}
+	return &claims, nil
+}
+
+func signJWT(signingInput, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -1,12 +1,15 @@
 package main
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // User represents a user in the system
 type User struct {
 	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
+	Username  string    `json:"username" validate:"required"`
+	Email     string    `json:"email" validate:"required,email"`
 	Password  string    `json:"password,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -17,12 +20,12 @@ type User struct {
 // Product represents a product in the catalog
 type Product struct {
 	ID          int     `json:"id"`
-	Name        string  `json:"name"`
+	Name        string  `json:"name" validate:"required"`
 	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Price       float64 `json:"price" validate:"min=0"`
 	Category    string  `json:"category"`
-	Stock       int     `json:"stock"`
-	SKU         string  `json:"sku"`
+	Stock       int     `json:"stock" validate:"min=0"`
+	SKU         string  `json:"sku" validate:"required"`
 	Weight      float64 `json:"weight"`
 	Dimensions  string  `json:"dimensions"`
 }
@@ -33,7 +36,7 @@ type Order struct {
 	UserID      int           `json:"user_id"`
 	Items       []OrderItem   `json:"items"`
 	TotalAmount float64       `json:"total_amount"`
-	Status      string        `json:"status"`
+	Status      string        `json:"status" validate:"oneof=pending paid shipped delivered cancelled"`
 	CreatedAt   time.Time     `json:"created_at"`
 	UpdatedAt   time.Time     `json:"updated_at"`
 	ShippingAddress Address   `json:"shipping_address"`
@@ -53,11 +56,11 @@ type OrderItem struct {
 // Address represents a physical address
 type Address struct {
 	ID       int    `json:"id"`
-	Street   string `json:"street"`
-	City     string `json:"city"`
+	Street   string `json:"street" validate:"required"`
+	City     string `json:"city" validate:"required"`
 	State    string `json:"state"`
-	ZipCode  string `json:"zip_code"`
-	Country  string `json:"country"`
+	ZipCode  string `json:"zip_code" validate:"required,regex=^[0-9A-Za-z -]{3,10}$"`
+	Country  string `json:"country" validate:"required"`
 	UserID   int    `json:"user_id"`
 	Type     string `json:"type"` // shipping or billing
 }
@@ -77,7 +80,7 @@ type Review struct {
 	ID        int       `json:"id"`
 	ProductID int       `json:"product_id"`
 	UserID    int       `json:"user_id"`
-	Rating    int       `json:"rating"`
+	Rating    int       `json:"rating" validate:"min=1,max=5"`
 	Comment   string    `json:"comment"`
 	CreatedAt time.Time `json:"created_at"`
 	Verified  bool      `json:"verified"`
@@ -174,6 +177,7 @@ type Config struct {
 	Server   ServerConfig   `json:"server"`
 	Auth     AuthConfig     `json:"auth"`
 	Cache    CacheConfig    `json:"cache"`
+	Log      LogConfig      `json:"log"`
 }
 
 // DatabaseConfig represents database configuration
@@ -195,9 +199,15 @@ type ServerConfig struct {
 
 // AuthConfig represents authentication configuration
 type AuthConfig struct {
-	JWTSecret     string `json:"jwt_secret"`
-	JWTExpiryHour int    `json:"jwt_expiry_hour"`
-	BcryptCost    int    `json:"bcrypt_cost"`
+	JWTSecret        string   `json:"jwt_secret"`
+	JWTExpiryHour    int      `json:"jwt_expiry_hour"`
+	BcryptCost       int      `json:"bcrypt_cost"`
+	OIDCIssuer       string   `json:"oidc_issuer"`
+	OIDCClientID     string   `json:"oidc_client_id"`
+	OIDCClientSecret string   `json:"oidc_client_secret"`
+	OIDCRedirectURL  string   `json:"oidc_redirect_url"`
+	OIDCScopes       []string `json:"oidc_scopes"`
+	SessionSecret    string   `json:"session_secret"`
 }
 
 // CacheConfig represents cache configuration
@@ -209,12 +219,24 @@ type CacheConfig struct {
 	TTL           int    `json:"ttl"`
 }
 
+// LogConfig represents logging configuration
+type LogConfig struct {
+	Level      string `json:"level"` // debug, info, warn, error
+	Format     string `json:"format"` // json or text
+	Output     string `json:"output"` // stdout or file
+	File       string `json:"file"`
+	MaxSizeMB  int    `json:"max_size_mb"`
+	MaxAgeDays int    `json:"max_age_days"`
+	WebhookURL string `json:"webhook_url"`
+}
+
 // Logger represents a logger interface
 type Logger interface {
 	Info(msg string, args ...interface{})
 	Error(msg string, args ...interface{})
 	Debug(msg string, args ...interface{})
 	Warn(msg string, args ...interface{})
+	WithContext(ctx context.Context) Logger
 }
 
 // Service represents a service interface
@@ -230,7 +252,7 @@ type Repository interface {
 	Update(id int, model interface{}) error
 	Delete(id int) error
 	FindByID(id int) (interface{}, error)
-	FindAll(params QueryParams) ([]interface{}, error)
+	FindAll(params QueryParams) ([]interface{}, Pagination, error)
 }
 
 // Validator represents a validator interface
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"math"
@@ -127,12 +132,108 @@ func (v ValidationUtils) IsAlpha(str string) bool {
 type HashUtils struct{}
 
 // MD5Hash generates MD5 hash of string
+//
+// Deprecated: MD5 is not safe for anything security-sensitive (passwords,
+// tokens, signatures). Use SHA256/SHA512 for digests or HashPassword for
+// credentials.
 func (h HashUtils) MD5Hash(str string) string {
 	hasher := md5.New()
 	hasher.Write([]byte(str))
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+// SHA256 generates the SHA-256 hash of str.
+func (h HashUtils) SHA256(str string) string {
+	sum := sha256.Sum256([]byte(str))
+	return hex.EncodeToString(sum[:])
+}
+
+// SHA512 generates the SHA-512 hash of str.
+func (h HashUtils) SHA512(str string) string {
+	sum := sha512.Sum512([]byte(str))
+	return hex.EncodeToString(sum[:])
+}
+
+// HMAC computes the HMAC of msg under secret using the named algorithm
+// ("sha256" or "sha512").
+func (h HashUtils) HMAC(secret, msg, algo string) (string, error) {
+	var mac hashMAC
+	switch algo {
+	case "sha256":
+		mac = hmac.New(sha256.New, []byte(secret))
+	case "sha512":
+		mac = hmac.New(sha512.New, []byte(secret))
+	default:
+		return "", fmt.Errorf("hash: unsupported HMAC algorithm %q", algo)
+	}
+	mac.Write([]byte(msg))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// hashMAC is the subset of hash.Hash an hmac.New return value satisfies.
+type hashMAC interface {
+	Write(p []byte) (int, error)
+	Sum(b []byte) []byte
+}
+
+const passwordSaltSize = 16
+
+// HashPassword derives a salted hash of pw. cost controls the number of
+// SHA-256 rounds applied (higher is slower and more resistant to
+// brute-force), the same role AuthConfig.BcryptCost plays for bcrypt.
+func (h HashUtils) HashPassword(pw string, cost int) (string, error) {
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	digest := derivePassword([]byte(pw), salt, cost)
+	return fmt.Sprintf("%d$%s$%s", cost, hex.EncodeToString(salt), hex.EncodeToString(digest)), nil
+}
+
+// VerifyPassword reports whether pw matches a hash produced by
+// HashPassword, in constant time.
+func (h HashUtils) VerifyPassword(pw, hash string) bool {
+	parts := strings.SplitN(hash, "$", 3)
+	if len(parts) != 3 {
+		return false
+	}
+
+	cost, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	salt, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	want, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	got := derivePassword([]byte(pw), salt, cost)
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// derivePassword runs pw through 2^cost rounds of salted SHA-256, capped
+// at 2^20 rounds so a misconfigured cost can't hang the process.
+func derivePassword(pw, salt []byte, cost int) []byte {
+	if cost <= 0 {
+		cost = 10
+	}
+	if cost > 20 {
+		cost = 20
+	}
+
+	digest := append(append([]byte{}, salt...), pw...)
+	sum := sha256.Sum256(digest)
+	rounds := 1 << uint(cost)
+	for i := 0; i < rounds; i++ {
+		sum = sha256.Sum256(sum[:])
+	}
+	return sum[:]
+}
+
 // SliceUtils contains slice utility functions
 type SliceUtils struct{}
 
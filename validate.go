@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RuleFunc validates a single field's value. args are the rule's
+// parameters (e.g. "5" for min=5); parent lets cross-field rules like
+// eqfield reach sibling values.
+type RuleFunc func(value reflect.Value, args string, parent reflect.Value) error
+
+// StructValidator is the default Validator implementation: it reflects
+// over a struct's fields, reads their `validate:"..."` tag, and runs each
+// named rule in turn.
+type StructValidator struct {
+	rules map[string]RuleFunc
+}
+
+// NewValidator returns a Validator preloaded with the built-in rule set.
+func NewValidator() *StructValidator {
+	v := &StructValidator{rules: map[string]RuleFunc{}}
+	v.registerBuiltins()
+	return v
+}
+
+// appValidator is the shared Validator instance the HTTP handlers use to
+// check request bodies before touching the service layer.
+var appValidator = NewValidator()
+
+// RegisterCustomValidation adds a rule (e.g. a SKU format check or a
+// password-strength rule) to appValidator, so callers of this module can
+// extend validation without editing the handlers.
+func RegisterCustomValidation(tag string, fn RuleFunc) {
+	appValidator.RegisterRule(tag, fn)
+}
+
+// ValidateRequest runs appValidator against v and, if it fails, writes a
+// 422 response with one {field, tag, message} entry per violation.
+func ValidateRequest(w http.ResponseWriter, v interface{}) bool {
+	errs := appValidator.Validate(v)
+	if len(errs) == 0 {
+		return true
+	}
+
+	entries := make([]map[string]string, len(errs))
+	for i, e := range errs {
+		entries[i] = map[string]string{"field": e.Field, "tag": e.Code, "message": e.Message}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": entries})
+	return false
+}
+
+// RegisterRule adds or overrides a named validation rule.
+func (v *StructValidator) RegisterRule(name string, fn RuleFunc) {
+	v.rules[name] = fn
+}
+
+// Validate reflects over model and runs every `validate` rule found.
+func (v *StructValidator) Validate(model interface{}) []ValidationError {
+	val := reflect.ValueOf(model)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []ValidationError
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			name, args := rule, ""
+			if idx := strings.Index(rule, "="); idx != -1 {
+				name, args = rule[:idx], rule[idx+1:]
+			}
+
+			fn, ok := v.rules[name]
+			if !ok {
+				continue
+			}
+			if err := fn(val.Field(i), args, val); err != nil {
+				errs = append(errs, ValidationError{
+					Field:   jsonName,
+					Message: err.Error(),
+					Code:    ruleCode(name),
+				})
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateVar runs a single ad-hoc rule string (e.g. "required,email")
+// against value, outside the context of a struct field.
+func (v *StructValidator) ValidateVar(value interface{}, rules string) []ValidationError {
+	rv := reflect.ValueOf(value)
+	var errs []ValidationError
+	for _, rule := range strings.Split(rules, ",") {
+		name, args := rule, ""
+		if idx := strings.Index(rule, "="); idx != -1 {
+			name, args = rule[:idx], rule[idx+1:]
+		}
+		fn, ok := v.rules[name]
+		if !ok {
+			continue
+		}
+		if err := fn(rv, args, reflect.Value{}); err != nil {
+			errs = append(errs, ValidationError{Field: "value", Message: err.Error(), Code: ruleCode(name)})
+		}
+	}
+	return errs
+}
+
+func ruleCode(rule string) string {
+	switch rule {
+	case "required":
+		return "E_REQUIRED"
+	case "min", "max", "len":
+		return "E_OUT_OF_RANGE"
+	case "email", "phone", "regex", "numeric", "alpha":
+		return "E_INVALID_FORMAT"
+	case "oneof":
+		return "E_NOT_ALLOWED"
+	case "eqfield", "nefield":
+		return "E_FIELD_MISMATCH"
+	default:
+		return "E_INVALID"
+	}
+}
+
+func (v *StructValidator) registerBuiltins() {
+	vu := ValidationUtils{}
+
+	v.rules["required"] = func(val reflect.Value, _ string, _ reflect.Value) error {
+		if isZero(val) {
+			return fmt.Errorf("is required")
+		}
+		return nil
+	}
+
+	v.rules["min"] = func(val reflect.Value, args string, _ reflect.Value) error {
+		n, err := strconv.ParseFloat(args, 64)
+		if err != nil {
+			return nil
+		}
+		if numericValue(val) < n {
+			return fmt.Errorf("must be at least %s", args)
+		}
+		return nil
+	}
+
+	v.rules["max"] = func(val reflect.Value, args string, _ reflect.Value) error {
+		n, err := strconv.ParseFloat(args, 64)
+		if err != nil {
+			return nil
+		}
+		if numericValue(val) > n {
+			return fmt.Errorf("must be at most %s", args)
+		}
+		return nil
+	}
+
+	v.rules["len"] = func(val reflect.Value, args string, _ reflect.Value) error {
+		n, err := strconv.Atoi(args)
+		if err != nil {
+			return nil
+		}
+		if lengthOf(val) != n {
+			return fmt.Errorf("must have length %s", args)
+		}
+		return nil
+	}
+
+	v.rules["email"] = func(val reflect.Value, _ string, _ reflect.Value) error {
+		if val.Kind() != reflect.String || val.String() == "" {
+			return nil
+		}
+		if !vu.IsEmail(val.String()) {
+			return fmt.Errorf("must be a valid email")
+		}
+		return nil
+	}
+
+	v.rules["phone"] = func(val reflect.Value, _ string, _ reflect.Value) error {
+		if val.Kind() != reflect.String || val.String() == "" {
+			return nil
+		}
+		if !vu.IsPhone(val.String()) {
+			return fmt.Errorf("must be a valid phone number")
+		}
+		return nil
+	}
+
+	v.rules["numeric"] = func(val reflect.Value, _ string, _ reflect.Value) error {
+		if val.Kind() != reflect.String {
+			return nil
+		}
+		if !vu.IsNumeric(val.String()) {
+			return fmt.Errorf("must be numeric")
+		}
+		return nil
+	}
+
+	v.rules["alpha"] = func(val reflect.Value, _ string, _ reflect.Value) error {
+		if val.Kind() != reflect.String {
+			return nil
+		}
+		if !vu.IsAlpha(val.String()) {
+			return fmt.Errorf("must contain only letters")
+		}
+		return nil
+	}
+
+	v.rules["oneof"] = func(val reflect.Value, args string, _ reflect.Value) error {
+		options := strings.Fields(args)
+		str := fmt.Sprintf("%v", val.Interface())
+		for _, o := range options {
+			if o == str {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s]", strings.Join(options, ", "))
+	}
+
+	v.rules["regex"] = func(val reflect.Value, args string, _ reflect.Value) error {
+		if val.Kind() != reflect.String {
+			return nil
+		}
+		re, err := regexp.Compile(args)
+		if err != nil {
+			return nil
+		}
+		if !re.MatchString(val.String()) {
+			return fmt.Errorf("does not match required format")
+		}
+		return nil
+	}
+
+	v.rules["eqfield"] = func(val reflect.Value, args string, parent reflect.Value) error {
+		other := parent.FieldByName(args)
+		if !other.IsValid() {
+			return nil
+		}
+		if fmt.Sprintf("%v", val.Interface()) != fmt.Sprintf("%v", other.Interface()) {
+			return fmt.Errorf("must match %s", args)
+		}
+		return nil
+	}
+
+	v.rules["nefield"] = func(val reflect.Value, args string, parent reflect.Value) error {
+		other := parent.FieldByName(args)
+		if !other.IsValid() {
+			return nil
+		}
+		if fmt.Sprintf("%v", val.Interface()) == fmt.Sprintf("%v", other.Interface()) {
+			return fmt.Errorf("must differ from %s", args)
+		}
+		return nil
+	}
+}
+
+func isZero(val reflect.Value) bool {
+	if !val.IsValid() {
+		return true
+	}
+	return val.Interface() == reflect.Zero(val.Type()).Interface()
+}
+
+func numericValue(val reflect.Value) float64 {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int())
+	case reflect.Float32, reflect.Float64:
+		return val.Float()
+	case reflect.String:
+		return float64(len(val.String()))
+	case reflect.Slice, reflect.Array:
+		return float64(val.Len())
+	default:
+		return 0
+	}
+}
+
+func lengthOf(val reflect.Value) int {
+	switch val.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return val.Len()
+	default:
+		return 0
+	}
+}